@@ -7,11 +7,14 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	resp "github.com/nicklaw5/go-respond"
+
+	"github.com/comov/hsearch/imagestore"
 )
 
 type Api struct {
 	router *httprouter.Router
 	st     Storage
+	images *imagestore.Store
 }
 
 type Response struct {
@@ -23,11 +26,13 @@ func (m *Manager) httpApi() {
 	srv := &Api{
 		router: httprouter.New(),
 		st:     m.st,
+		images: m.images,
 	}
 	srv.router.NotFound = http.HandlerFunc(srv.NotFound)
 	srv.router.MethodNotAllowed = http.HandlerFunc(srv.MethodNotAllowed)
 
 	srv.router.GET("/ping", srv.ping)
+	srv.router.GET("/v1/images/:key/:file", srv.image)
 
 	//srv.router.GET("/v1/offer", srv.offerList)
 	//srv.router.POST("/v1/offers/:id", srv.sayAnswer)
@@ -63,6 +68,34 @@ func (api *Api) ping(w http.ResponseWriter, _ *http.Request, _ httprouter.Params
 	return
 }
 
+// image - returns our copy of the offer photo. The file is the size with the
+//  extension, for example large.jpg
+func (api *Api) image(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if api.images == nil {
+		api.NotFound(w, r)
+		return
+	}
+
+	key := ps.ByName("key")
+	size := strings.TrimSuffix(ps.ByName("file"), ".jpg")
+	if !imagestore.ValidKey(key) {
+		api.NotFound(w, r)
+		return
+	}
+
+	data, err := api.images.Load(r.Context(), key, size)
+	if err != nil {
+		log.Printf("[api.image] %s/%s error: %s\n", key, size, err)
+		api.NotFound(w, r)
+		return
+	}
+
+	// the key is a hash of the content, so the photo never changes
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	_, _ = w.Write(data)
+}
+
 func (api *Api) offerList(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	return
 }
@@ -2,11 +2,14 @@ package background
 
 import (
 	"context"
+	"log"
 
 	"github.com/PuerkitoBio/goquery"
 
 	"github.com/comov/hsearch/configs"
+	"github.com/comov/hsearch/imagestore"
 	"github.com/comov/hsearch/parser"
+	"github.com/comov/hsearch/scam"
 	"github.com/comov/hsearch/structs"
 )
 
@@ -15,13 +18,36 @@ type (
 		WriteOffers(ctx context.Context, offer []*structs.Offer) (int, error)
 		ReadChatsForMatching(ctx context.Context, enable int) ([]*structs.Chat, error)
 		ReadNextOffer(ctx context.Context, chat *structs.Chat) (*structs.Offer, error)
+		ReadSearchProfiles(ctx context.Context, chat int64) ([]*structs.SearchProfile, error)
 		CleanFromExistOrders(ctx context.Context, offers map[uint64]string, siteName string) error
+		ReadClusterCandidates(ctx context.Context, offers []*structs.Offer, since int64) ([]*structs.Offer, error)
+		UpdateSamePhotos(ctx context.Context, offers []*structs.Offer) error
+		ReadMarketMedians(ctx context.Context) (map[string]scam.Median, error)
+		ReadReportedPhones(ctx context.Context, phones []string) (map[string]bool, error)
+		ReadDueViewings(ctx context.Context, till, since int64) ([]*structs.Viewing, error)
+		SetViewingReminded(ctx context.Context, id int64) error
+		ReadDueSnoozes(ctx context.Context, chatId int64, now int64) ([]*structs.Snooze, error)
+		ReadSnoozesToCheck(ctx context.Context, now int64, limit int) ([]*structs.Offer, error)
+		SetSnoozeChecked(ctx context.Context, offerId uint64, now int64, checked string) error
+		AddSnoozeAttempt(ctx context.Context, offerId uint64, now int64) (int, error)
+		DeleteSnooze(ctx context.Context, offerId uint64, chatId int64) error
+		UpdateMarketStats(ctx context.Context, since, now int64) error
 
 		// GarbageCollector methods
-		CleanExpiredOffers(ctx context.Context, expireDate int64) error
-		CleanExpiredImages(ctx context.Context, expireDate int64) error
-		CleanExpiredAnswers(ctx context.Context, expireDate int64) error
-		CleanExpiredTGMessages(ctx context.Context, expireDate int64) error
+		CleanExpiredOffers(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredImages(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredAnswers(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredTGMessages(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredVotes(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredViewings(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredNotes(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredSnoozes(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredStatuses(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredReports(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredArchive(ctx context.Context, expireDate int64) (int64, error)
+		CleanExpiredFeedback(ctx context.Context, expireDate int64) (int64, error)
+		ReadUnusedImageKeys(ctx context.Context, limit int) ([]string, error)
+		ForgetImageKeys(ctx context.Context, keys []string) error
 
 		UpdateSettings(ctx context.Context, chat *structs.Chat) error
 	}
@@ -29,6 +55,8 @@ type (
 	Bot interface {
 		SendOffer(ctx context.Context, offer *structs.Offer, chat *structs.Chat) error
 		SendError(where string, err error, chatId int64)
+		SendViewingReminder(ctx context.Context, viewing *structs.Viewing) error
+		SendSnoozeGone(ctx context.Context, offer *structs.Offer, chatId int64) error
 	}
 
 	Site interface {
@@ -40,22 +68,30 @@ type (
 		GetOffersMap(doc *goquery.Document) parser.OffersMap
 		IdFromHref(href string) (uint64, error)
 		ParseNewOffer(href string, exId uint64, doc *goquery.Document) *structs.Offer
+		IsRemoved(doc *goquery.Document) bool
 	}
 
 	Manager struct {
 		st            Storage
 		bot           Bot
 		cnf           *configs.Config
+		images        *imagestore.Store
 		sitesForParse []Site
 	}
 )
 
 // NewManager - initializes the new background manager
 func NewManager(cnf *configs.Config, st Storage, bot Bot) *Manager {
+	images, err := imagestore.New(cnf)
+	if err != nil {
+		log.Fatalln("[background.imagestore.New] error: ", err)
+	}
+
 	return &Manager{
-		st:  st,
-		bot: bot,
-		cnf: cnf,
+		st:     st,
+		bot:    bot,
+		cnf:    cnf,
+		images: images,
 		sitesForParse: []Site{
 			parser.DieselSite(),
 			parser.HouseSite(),
@@ -64,7 +100,8 @@ func NewManager(cnf *configs.Config, st Storage, bot Bot) *Manager {
 	}
 }
 
-// StartGarbageCollector - runs garbage collection in the form of old records that no longer make sense
+// StartGarbageCollector - runs garbage collection in the form of old records
+//  that no longer make sense, on start and then by the schedule
 func (m *Manager) StartGarbageCollector() {
 	m.garbage()
 }
@@ -79,6 +116,22 @@ func (m *Manager) StartMatcher() {
 	m.matcher()
 }
 
+// StartReminder - starts the reminders about the viewings
+func (m *Manager) StartReminder() {
+	m.reminder()
+}
+
+// StartSnoozeChecker - starts checking that the snoozed offers are still
+//  on the sites
+func (m *Manager) StartSnoozeChecker() {
+	m.snoozeChecker()
+}
+
+// StartStatistics - starts counting the market prices
+func (m *Manager) StartStatistics() {
+	m.statistics()
+}
+
 // StartApi - starts the HTTP api service
 func (m *Manager) StartApi() {
 	m.httpApi()
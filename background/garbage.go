@@ -2,66 +2,120 @@ package background
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/getsentry/sentry-go"
 )
 
+// imageFilesBatch - how many stored photos are deleted at once
+const imageFilesBatch = 500
+
+// garbageTable - how to clean one table and how many days its records live
+type garbageTable struct {
+	name  string
+	days  int
+	clean func(ctx context.Context, expireDate int64) (int64, error)
+}
+
+// garbage - cleans the old records on start and then every GarbageTime
 func (m *Manager) garbage() {
-	expireDate := time.Now().AddDate(0, 0, m.cnf.ExpireDays*-1).Unix()
-	ctx := context.Background()
+	sleep := time.Second * 5
 
 	log.Printf("[garbage] StartGarbageCollector Manager\n")
-	err := m.st.CleanExpiredOffers(ctx, expireDate)
-	if err != nil {
-		sentry.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "garbage",
-			Data: map[string]interface{}{
-				"method": "CleanExpiredOffers",
-				"expireDate": expireDate,
-			},
-		})
-		sentry.CaptureException(err)
-		log.Printf("[garbage.CleanExpiredOffers] Error: %s\n", err)
+	for {
+		select {
+		case <-time.After(sleep):
+			sleep = m.cnf.GarbageTime
+			m.collectGarbage(context.Background())
+		}
+	}
+}
+
+// collectGarbage - cleans every table with its retention and logs how many
+//  records are deleted from each of them. The blacklist is not here, it
+//  blocks the phones for the next offers too.
+func (m *Manager) collectGarbage(ctx context.Context) {
+	tables := []garbageTable{
+		{"offer", m.cnf.OfferExpireDays, m.st.CleanExpiredOffers},
+		{"image", m.cnf.ImageExpireDays, m.st.CleanExpiredImages},
+		{"answer", m.cnf.AnswerExpireDays, m.st.CleanExpiredAnswers},
+		{"tg_messages", m.cnf.MessageExpireDays, m.st.CleanExpiredTGMessages},
+		{"feedback", m.cnf.FeedbackExpireDays, m.st.CleanExpiredFeedback},
+		{"vote", m.cnf.VoteExpireDays, m.st.CleanExpiredVotes},
+		{"viewing", m.cnf.ViewingExpireDays, m.st.CleanExpiredViewings},
+		{"note", m.cnf.NoteExpireDays, m.st.CleanExpiredNotes},
+		{"snooze", m.cnf.SnoozeExpireDays, m.st.CleanExpiredSnoozes},
+		{"offer_status", m.cnf.StatusExpireDays, m.st.CleanExpiredStatuses},
+		{"report", m.cnf.ReportExpireDays, m.st.CleanExpiredReports},
+		{"archive", m.cnf.ArchiveDays, m.st.CleanExpiredArchive},
 	}
 
-	err = m.st.CleanExpiredImages(ctx, expireDate)
-	if err != nil {
-		sentry.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "garbage",
-			Data: map[string]interface{}{
-				"method": "CleanExpiredImages",
-				"expireDate": expireDate,
-			},
-		})
-		sentry.CaptureException(err)
-		log.Printf("[garbage.CleanExpiredImages] Error: %s\n", err)
+	now := time.Now()
+	counts := make([]string, 0, len(tables))
+	for _, table := range tables {
+		expireDate := now.AddDate(0, 0, table.days*-1).Unix()
+		count, err := table.clean(ctx, expireDate)
+		if err != nil {
+			sentry.AddBreadcrumb(&sentry.Breadcrumb{
+				Category: "garbage",
+				Data: map[string]interface{}{
+					"table":      table.name,
+					"expireDate": expireDate,
+				},
+			})
+			sentry.CaptureException(err)
+			log.Printf("[garbage.%s] Error: %s\n", table.name, err)
+			continue
+		}
+		counts = append(counts, fmt.Sprintf("%s=%d", table.name, count))
 	}
 
-	err = m.st.CleanExpiredAnswers(ctx, expireDate)
-	if err != nil {
-		sentry.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "garbage",
-			Data: map[string]interface{}{
-				"method": "CleanExpiredAnswers",
-				"expireDate": expireDate,
-			},
-		})
-		sentry.CaptureException(err)
-		log.Printf("[garbage.CleanExpiredAnswers] Error: %s\n", err)
+	if m.images != nil {
+		counts = append(counts, fmt.Sprintf("files=%d", m.cleanImageFiles(ctx)))
 	}
 
-	err = m.st.CleanExpiredTGMessages(ctx, expireDate)
-	if err != nil {
-		sentry.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "garbage",
-			Data: map[string]interface{}{
-				"method": "CleanExpiredTGMessages",
-				"expireDate": expireDate,
-			},
-		})
-		sentry.CaptureException(err)
-		log.Printf("[garbage.CleanExpiredTGMessages] Error: %s\n", err)
+	log.Printf("[garbage] deleted: %s\n", strings.Join(counts, " "))
+}
+
+// cleanImageFiles - deletes the stored photos which no offer uses anymore and
+//  returns how many photos are deleted
+func (m *Manager) cleanImageFiles(ctx context.Context) int {
+	deleted := 0
+	for {
+		keys, err := m.st.ReadUnusedImageKeys(ctx, imageFilesBatch)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Printf("[garbage.ReadUnusedImageKeys] Error: %s\n", err)
+			return deleted
+		}
+
+		removed := make([]string, 0, len(keys))
+		for _, key := range keys {
+			err = m.images.Delete(ctx, key)
+			if err != nil {
+				sentry.CaptureException(err)
+				log.Printf("[garbage.images.Delete] %s Error: %s\n", key, err)
+				continue
+			}
+			removed = append(removed, key)
+		}
+
+		if len(removed) != 0 {
+			err = m.st.ForgetImageKeys(ctx, removed)
+			if err != nil {
+				sentry.CaptureException(err)
+				log.Printf("[garbage.ForgetImageKeys] Error: %s\n", err)
+				return deleted
+			}
+			deleted += len(removed)
+		}
+
+		// the keys which failed to be deleted are read again, so stop here
+		if len(keys) < imageFilesBatch || len(removed) != len(keys) {
+			return deleted
+		}
 	}
 }
@@ -7,7 +7,10 @@ import (
 
 	"github.com/getsentry/sentry-go"
 
+	"github.com/comov/hsearch/dedup"
 	"github.com/comov/hsearch/parser"
+	"github.com/comov/hsearch/scam"
+	"github.com/comov/hsearch/structs"
 )
 
 // todo: refactor this
@@ -58,9 +61,73 @@ func (m *Manager) grabbedOffers(ctx context.Context, site Site) {
 	offers := parser.LoadOffersDetail(offersLinks, site)
 	log.Printf("[grabber] Find %d new offers for site `%s`\n", len(offers), site.Name())
 
+	m.loadImages(ctx, offers)
+	candidates := m.clusterize(ctx, offers)
+	changed := dedup.CountSamePhotos(offers, candidates)
+	m.scoreScam(ctx, offers, candidates)
+
 	_, err = m.st.WriteOffers(ctx, offers)
 	if err != nil {
 		sentry.CaptureException(err)
 		log.Printf("[grabber.WriteOffer] Error: %s\n", err)
+		return
+	}
+
+	err = m.st.UpdateSamePhotos(ctx, changed)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Printf("[grabber.UpdateSamePhotos] Error: %s\n", err)
+	}
+}
+
+// clusterize - finds the same flats among the new offers and offers which
+//  are already in the database and groups them into clusters. Returns the
+//  offers from the database which were compared.
+func (m *Manager) clusterize(ctx context.Context, offers []*structs.Offer) []*structs.Offer {
+	for _, offer := range offers {
+		dedup.Prepare(offer)
+	}
+
+	since := time.Now().AddDate(0, 0, m.cnf.ExpireDays*-1).Unix()
+	candidates, err := m.st.ReadClusterCandidates(ctx, offers, since)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Printf("[grabber.ReadClusterCandidates] Error: %s\n", err)
+	}
+
+	dedup.Clusterize(offers, candidates)
+	return candidates
+}
+
+// scoreScam - scores how suspicious the new offers are. Known are the offers
+//  from the database with the same phones, prices or photos. The medians are
+//  the market statistics, they skip the suspicious offers.
+func (m *Manager) scoreScam(ctx context.Context, offers, known []*structs.Offer) {
+	medians, err := m.st.ReadMarketMedians(ctx)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Printf("[grabber.ReadMarketMedians] Error: %s\n", err)
+	}
+
+	phones := make([]string, 0, len(offers))
+	for _, offer := range offers {
+		if offer.PhoneNorm != "" {
+			phones = append(phones, offer.PhoneNorm)
+		}
+	}
+
+	reported, err := m.st.ReadReportedPhones(ctx, phones)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Printf("[grabber.ReadReportedPhones] Error: %s\n", err)
+	}
+
+	signals := &scam.Signals{
+		Medians:        medians,
+		ReportedPhones: reported,
+		Known:          append(known, offers...),
+	}
+	for _, offer := range offers {
+		offer.ScamScore, offer.ScamReasons = scam.Score(offer, signals)
 	}
 }
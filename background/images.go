@@ -0,0 +1,79 @@
+package background
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/comov/hsearch/dedup"
+	"github.com/comov/hsearch/imagestore"
+	"github.com/comov/hsearch/parser"
+	"github.com/comov/hsearch/structs"
+)
+
+const (
+	// maxHashedImages - the first photos are enough to find the same offers,
+	//  the rest are loaded only to keep their copies, up to ImageLimit
+	maxHashedImages = 8
+
+	// imageLoaders - how many images are loaded at the same time
+	imageLoaders = 10
+)
+
+// loadImages - loads photos of the new offers, calculates their perceptual
+//  hashes and saves copies to the image store
+func (m *Manager) loadImages(ctx context.Context, offers []*structs.Offer) {
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, imageLoaders)
+
+	for _, offer := range offers {
+		offer.Photos = make([]*structs.Image, 0, len(offer.ImagesList))
+		for i, path := range offer.ImagesList {
+			img := &structs.Image{Path: path}
+			offer.Photos = append(offer.Photos, img)
+			if i >= maxHashedImages && (m.images == nil || i >= m.cnf.ImageLimit) {
+				continue
+			}
+
+			wg.Add(1)
+			go func(img *structs.Image) {
+				defer wg.Done()
+				limit <- struct{}{}
+				defer func() { <-limit }()
+
+				m.loadImage(ctx, img)
+			}(img)
+		}
+	}
+
+	wg.Wait()
+}
+
+func (m *Manager) loadImage(ctx context.Context, img *structs.Image) {
+	data, err := parser.GetImageByUrl(img.Path)
+	if err != nil {
+		log.Printf("[grabber.GetImageByUrl] Error: %s\n", err)
+		return
+	}
+
+	// the picture is decoded once for the hash and the resized versions, the
+	//  header is checked before the whole picture is decoded
+	picture, err := imagestore.Decode(data)
+	if err != nil {
+		log.Printf("[grabber.Decode] %s Error: %s\n", img.Path, err)
+		return
+	}
+	img.Hash = dedup.DHash(picture)
+
+	if m.images == nil {
+		return
+	}
+
+	img.Key, err = m.images.Save(ctx, data, picture)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Printf("[grabber.images.Save] %s Error: %s\n", img.Path, err)
+	}
+}
@@ -0,0 +1,34 @@
+package background
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// statisticsFrequency - how often the market prices are counted again
+const statisticsFrequency = time.Hour
+
+// statistics - counts the market prices of the flats for the last
+//  MarketDays days. The first count is right after the start.
+func (m *Manager) statistics() {
+	sleep := time.Second * 10
+
+	log.Printf("[statistics] StartStatistics Manager\n")
+	for {
+		select {
+		case <-time.After(sleep):
+			sleep = statisticsFrequency
+			now := time.Now()
+			since := now.AddDate(0, 0, m.cnf.MarketDays*-1).Unix()
+
+			err := m.st.UpdateMarketStats(context.Background(), since, now.Unix())
+			if err != nil {
+				sentry.CaptureException(err)
+				log.Printf("[statistics.UpdateMarketStats] Error: %s\n", err)
+			}
+		}
+	}
+}
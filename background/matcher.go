@@ -46,10 +46,45 @@ func (m *Manager) matcher() {
 	}
 }
 
+// matching - sends the next offer for every enabled search profile of the
+//  chat or, if the chat has no enabled profiles, by the chat filters
 func (m *Manager) matching(ctx context.Context, chat *structs.Chat) {
 	log.Printf("[matcher] Startmatcher matching for `%s`\n", chat.Title)
 
-	offer, err := m.st.ReadNextOffer(ctx, chat)
+	m.matchSnoozed(ctx, chat)
+
+	profiles, err := m.st.ReadSearchProfiles(ctx, chat.Id)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Printf("[matcher] Can't read profiles for %s with an error: %s\n", chat.Title, err)
+		return
+	}
+
+	enabled := 0
+	for _, profile := range profiles {
+		if !profile.Enable {
+			continue
+		}
+
+		enabled++
+		if !m.matchOffer(ctx, chat, profile.Filters, profile.Name) {
+			return
+		}
+	}
+
+	if enabled == 0 {
+		m.matchOffer(ctx, chat, chat.Filters, "")
+	}
+}
+
+// matchOffer - sends the next offer which passes the filters and labels it
+//  with the profile. Returns false if the chat does not receive offers
+//  anymore.
+func (m *Manager) matchOffer(ctx context.Context, chat *structs.Chat, filters structs.Filters, profile string) bool {
+	filtered := *chat
+	filtered.Filters = filters
+
+	offer, err := m.st.ReadNextOffer(ctx, &filtered)
 	if err != nil {
 		sentry.AddBreadcrumb(&sentry.Breadcrumb{
 			Category: "matcher",
@@ -57,17 +92,19 @@ func (m *Manager) matching(ctx context.Context, chat *structs.Chat) {
 				"method": "ReadNextOffer",
 				"chat.id": chat.Id,
 				"chat.title": chat.Title,
+				"profile": profile,
 			},
 		})
 		log.Printf("[matcher] Can't read offer for %s with an error: %s\n", chat.Title, err)
-		return
+		return true
 	}
 
 	if offer == nil {
 		log.Printf("[matcher] For `%s` not new offers\n", chat.Title)
-		return
+		return true
 	}
 
+	offer.Profile = profile
 	err = m.bot.SendOffer(ctx, offer, chat)
 	if err != nil {
 		if strings.Contains(err.Error(), "blocked by the user") {
@@ -75,7 +112,7 @@ func (m *Manager) matching(ctx context.Context, chat *structs.Chat) {
 			if err = m.st.UpdateSettings(ctx, chat); err != nil {
 				sentry.CaptureException(err)
 			}
-			return
+			return false
 		}
 
 		sentry.AddBreadcrumb(&sentry.Breadcrumb{
@@ -91,8 +128,39 @@ func (m *Manager) matching(ctx context.Context, chat *structs.Chat) {
 		})
 		sentry.CaptureException(err)
 		log.Printf("[matcher] Can't send message for `%s` with an error: %s\n", chat.Title, err)
-		return
+		return true
 	}
 
 	log.Printf("[matcher] Successfully send offer %d for `%s`\n", offer.Id, chat.Title)
+	return true
+}
+
+// matchSnoozed - sends again the offers which the chat asked to remind later.
+//  The offer may be already rented, the snooze checker finds it out before.
+func (m *Manager) matchSnoozed(ctx context.Context, chat *structs.Chat) {
+	snoozes, err := m.st.ReadDueSnoozes(ctx, chat.Id, time.Now().Unix())
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Printf("[matcher.ReadDueSnoozes] Error: %s\n", err)
+		return
+	}
+
+	for _, snooze := range snoozes {
+		if snooze.Checked == structs.SnoozeGone {
+			err = m.bot.SendSnoozeGone(ctx, snooze.Offer, chat.Id)
+		} else {
+			err = m.bot.SendOffer(ctx, snooze.Offer, chat)
+		}
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Printf("[matcher] Can't send snoozed offer for `%s` with an error: %s\n", chat.Title, err)
+			continue
+		}
+
+		err = m.st.DeleteSnooze(ctx, snooze.OfferId, chat.Id)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Printf("[matcher.DeleteSnooze] Error: %s\n", err)
+		}
+	}
 }
@@ -0,0 +1,49 @@
+package background
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// staleViewing - the viewings which started longer ago are not reminded
+//  about, the chat already went there or missed it
+const staleViewing = time.Hour
+
+// reminder - reminds the chats about the viewings some time before them
+func (m *Manager) reminder() {
+	log.Printf("[reminder] StartReminder Manager\n")
+	for {
+		select {
+		case <-time.After(time.Minute):
+			ctx := context.Background()
+			now := time.Now()
+			till := now.Add(m.cnf.ViewingRemindTime).Unix()
+			since := now.Add(-staleViewing).Unix()
+
+			viewings, err := m.st.ReadDueViewings(ctx, till, since)
+			if err != nil {
+				sentry.CaptureException(err)
+				log.Printf("[reminder.ReadDueViewings] Error: %s\n", err)
+				continue
+			}
+
+			for _, viewing := range viewings {
+				err = m.bot.SendViewingReminder(ctx, viewing)
+				if err != nil {
+					sentry.CaptureException(err)
+					log.Printf("[reminder.SendViewingReminder] Error: %s\n", err)
+					continue
+				}
+
+				err = m.st.SetViewingReminded(ctx, viewing.Id)
+				if err != nil {
+					sentry.CaptureException(err)
+					log.Printf("[reminder.SetViewingReminded] Error: %s\n", err)
+				}
+			}
+		}
+	}
+}
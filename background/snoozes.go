@@ -0,0 +1,84 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/comov/hsearch/parser"
+	"github.com/comov/hsearch/structs"
+)
+
+const (
+	// snoozeChecks - how many snoozed offers are checked in one round
+	snoozeChecks = 50
+	// maxSnoozeAttempts - after so many failed checks the offer is sent as
+	//  it is, the chat asked to remind about it anyway
+	maxSnoozeAttempts = 5
+)
+
+// snoozeChecker - checks that the due snoozed offers are still on the sites,
+//  so the matcher does not wait for the sites
+func (m *Manager) snoozeChecker() {
+	log.Printf("[snoozes] StartSnoozeChecker Manager\n")
+	for {
+		select {
+		case <-time.After(time.Minute):
+			m.checkSnoozes(context.Background())
+		}
+	}
+}
+
+// checkSnoozes - one round of the snooze checker
+func (m *Manager) checkSnoozes(ctx context.Context) {
+	now := time.Now().Unix()
+	offers, err := m.st.ReadSnoozesToCheck(ctx, now, snoozeChecks)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Printf("[snoozes.ReadSnoozesToCheck] Error: %s\n", err)
+		return
+	}
+
+	for _, offer := range offers {
+		alive, err := parser.IsOfferAlive(m.site(offer.Site), offer.Url)
+		if err != nil {
+			log.Printf("[snoozes.IsOfferAlive] offer %d error: %s\n", offer.Id, err)
+
+			attempts, err := m.st.AddSnoozeAttempt(ctx, offer.Id, now)
+			if err != nil {
+				sentry.CaptureException(err)
+				log.Printf("[snoozes.AddSnoozeAttempt] Error: %s\n", err)
+				continue
+			}
+			if attempts < maxSnoozeAttempts {
+				continue
+			}
+
+			sentry.CaptureMessage(fmt.Sprintf("offer %d is not checked after %d attempts", offer.Id, attempts))
+			alive = true
+		}
+
+		checked := structs.SnoozeAlive
+		if !alive {
+			checked = structs.SnoozeGone
+		}
+		err = m.st.SetSnoozeChecked(ctx, offer.Id, now, checked)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Printf("[snoozes.SetSnoozeChecked] Error: %s\n", err)
+		}
+	}
+}
+
+// site - the parser of the site by the name, nil for the unknown sites
+func (m *Manager) site(name string) parser.Site {
+	for _, site := range m.sitesForParse {
+		if site.Name() == name {
+			return site
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/dedup"
+	"github.com/comov/hsearch/structs"
+)
+
+// blockPhone - "Не показывать от этого номера" under the card adds the phone
+//  to the blacklist of the chat and removes the card
+func (b *Bot) blockPhone(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	offerId, err := b.queryOfferId(ctx, query)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[blockPhone.queryOfferId] error:", err)
+		return
+	}
+
+	phone, err := b.storage.BlacklistOfferPhone(ctx, chatId, offerId, "button")
+	if err != nil {
+		b.SendError("blockPhone.BlacklistOfferPhone", err, chatId)
+		return
+	}
+
+	text := blockNoPhoneText
+	if phone != "" {
+		text = fmt.Sprintf(blockedText, phone)
+		err = b.removeOffer(ctx, offerId, chatId)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[blockPhone.removeOffer] error:", err)
+		}
+	}
+
+	_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, text))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[blockPhone.AnswerCallbackQuery] error:", err)
+	}
+}
+
+// blacklistCommand - /blacklist shows the blacklist of the chat, the admin
+//  can see the global one with `/blacklist global`
+func (b *Bot) blacklistCommand(ctx context.Context, req *Request) {
+	if strings.TrimSpace(req.Message.CommandArguments()) == "global" && b.isAdmin(req) {
+		b.sendBlacklist(ctx, req.Chat.ID, structs.GlobalBlacklist, 0)
+		return
+	}
+	b.sendBlacklist(ctx, req.Chat.ID, req.Chat.ID, 0)
+}
+
+// blacklistCallback - `bl:del:id` removes the phone from the blacklist of the
+//  chat, `bl:gdel:id` from the global one
+func (b *Bot) blacklistCallback(ctx context.Context, req *Request) {
+	parts := strings.Split(req.Callback.Data, ":")
+	if len(parts) != 3 {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	owner := req.Chat.ID
+	switch {
+	case parts[1] == "del":
+	case parts[1] == "gdel" && b.isAdmin(req):
+		owner = structs.GlobalBlacklist
+	default:
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	err = b.storage.DeleteBlacklist(ctx, owner, id)
+	if err != nil {
+		b.SendError("blacklistCallback.DeleteBlacklist", err, req.Chat.ID)
+		return
+	}
+
+	_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(req.Callback.ID, unblockedText))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[blacklistCallback.AnswerCallbackQuery] error:", err)
+	}
+	b.sendBlacklist(ctx, req.Chat.ID, owner, req.Message.MessageID)
+}
+
+// banCommand - `/ban phone reason` adds the phone to the global blacklist
+func (b *Bot) banCommand(ctx context.Context, req *Request) {
+	phone, reason := splitPhone(req.Message.CommandArguments())
+	if phone == "" {
+		b.sendText(req.Chat.ID, banWrongText)
+		return
+	}
+
+	err := b.storage.BlacklistPhone(ctx, structs.GlobalBlacklist, phone, reason)
+	if err != nil {
+		b.SendError("banCommand.BlacklistPhone", err, req.Chat.ID)
+		return
+	}
+	b.sendText(req.Chat.ID, fmt.Sprintf(bannedText, phone))
+}
+
+// unbanCommand - `/unban phone` removes the phone from the global blacklist
+func (b *Bot) unbanCommand(ctx context.Context, req *Request) {
+	phone, _ := splitPhone(req.Message.CommandArguments())
+	if phone == "" {
+		b.sendText(req.Chat.ID, banWrongText)
+		return
+	}
+
+	err := b.storage.DeleteBlacklistPhone(ctx, structs.GlobalBlacklist, phone)
+	if err != nil {
+		b.SendError("unbanCommand.DeleteBlacklistPhone", err, req.Chat.ID)
+		return
+	}
+	b.sendText(req.Chat.ID, fmt.Sprintf(unbannedText, phone))
+}
+
+// sendBlacklist - sends the blacklist of the owner to the chat or shows it
+//  in the message
+func (b *Bot) sendBlacklist(ctx context.Context, chatId, owner int64, messageId int) {
+	entries, err := b.storage.ReadBlacklist(ctx, owner)
+	if err != nil {
+		b.SendError("sendBlacklist.ReadBlacklist", err, chatId)
+		return
+	}
+
+	action, title := "del", blacklistTitleText
+	if owner == structs.GlobalBlacklist {
+		action, title = "gdel", blacklistGlobalTitleText
+	}
+
+	var text strings.Builder
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(entries))
+	if len(entries) == 0 {
+		text.WriteString(blacklistEmptyText)
+	} else {
+		text.WriteString(title)
+	}
+
+	for i, entry := range entries {
+		text.WriteString(fmt.Sprintf("%d. %s", i+1, entry.Phone))
+		if entry.Reason != "" {
+			text.WriteString(" - " + entry.Reason)
+		}
+		text.WriteString("\n")
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("%d. Убрать %s", i+1, entry.Phone),
+				fmt.Sprintf("bl:%s:%d", action, entry.Id),
+			),
+		))
+	}
+
+	err = b.sendList(chatId, messageId, text.String(), rows)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendBlacklist.Send] error:", err)
+	}
+}
+
+// splitPhone - the normalized phone and the rest of the text. The phone may
+//  be written with spaces: "+996 555 123 456 агентство".
+func splitPhone(text string) (string, string) {
+	fields := strings.Fields(text)
+	digits := ""
+	for i, field := range fields {
+		if strings.Trim(field, "+-()0123456789") != "" {
+			return dedup.NormalizePhone(digits), strings.Join(fields[i:], " ")
+		}
+		digits += field
+	}
+	return dedup.NormalizePhone(digits), ""
+}
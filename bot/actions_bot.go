@@ -9,15 +9,18 @@ import (
 	"github.com/getsentry/sentry-go"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 	"github.com/jackc/pgx/v4"
+
+	"github.com/comov/hsearch/bot/fsm"
 )
 
 const feedBackWait = time.Minute * 5
 
-func (b *Bot) help(_ *tgbotapi.Message) string {
+func (b *Bot) help(_ context.Context, _ *tgbotapi.Message) string {
 	return helpMessage
 }
 
-func (b *Bot) start(ctx context.Context, chat *tgbotapi.Chat) string {
+func (b *Bot) start(ctx context.Context, message *tgbotapi.Message) string {
+	chat := message.Chat
 	_, err := b.storage.ReadChat(ctx, chat.ID)
 	if err == nil {
 		return "Я уже работаю на тебя"
@@ -43,8 +46,8 @@ func (b *Bot) start(ctx context.Context, chat *tgbotapi.Chat) string {
 	return "Теперь я буду искать для тебя квартиры"
 }
 
-func (b *Bot) stop(ctx context.Context, chat *tgbotapi.Chat) string {
-	err := b.storage.DeleteChat(ctx, chat.ID)
+func (b *Bot) stop(ctx context.Context, message *tgbotapi.Message) string {
+	err := b.storage.DeleteChat(ctx, message.Chat.ID)
 	if err != nil {
 		sentry.CaptureException(err)
 		log.Println("[stop.DeleteChat] error:", err)
@@ -53,15 +56,17 @@ func (b *Bot) stop(ctx context.Context, chat *tgbotapi.Chat) string {
 	return "Я больше не буду искать для тебя квартиры"
 }
 
-func (b *Bot) feedback(_ context.Context, chat *tgbotapi.Chat) string {
-	b.addWaitCallback(chat.ID, answer{
-		deadline: time.Now().Add(feedBackWait),
-		callback: b.feedbackWaiterCallback,
-	})
+func (b *Bot) feedback(ctx context.Context, message *tgbotapi.Message) string {
+	err := b.startDialog(ctx, message.Chat.ID, stateFeedback, 0, nil)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[feedback.startDialog] error:", err)
+		return somethingWrong
+	}
 	return feedbackText
 }
 
-func (b *Bot) feedbackWaiterCallback(ctx context.Context, message *tgbotapi.Message, _ answer) {
+func (b *Bot) feedbackWaiterCallback(ctx context.Context, message *tgbotapi.Message, _ *fsm.State) (string, error) {
 	msgText := "Понял, передам!"
 	err := b.storage.Feedback(ctx, message.Chat.ID, message.Chat.UserName, message.Text)
 	if err != nil {
@@ -83,4 +88,5 @@ func (b *Bot) feedbackWaiterCallback(ctx context.Context, message *tgbotapi.Mess
 			log.Println("[feedbackWaiterCallback.Send2] error:", err)
 		}
 	}
+	return fsm.Done, nil
 }
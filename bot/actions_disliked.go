@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/structs"
+)
+
+const (
+	// undoWindow - how long the "Отменить" message lives after the dislike
+	undoWindow = time.Second * 30
+
+	// dislikedLimit - how many last dislikes /disliked shows
+	dislikedLimit = 10
+)
+
+// sendUndoDislike - sends the message with the reasons of the dislike and
+//  the button to cancel it, the message is deleted after undoWindow
+func (b *Bot) sendUndoDislike(chatId int64, offerId uint64) {
+	message := tgbotapi.NewMessage(chatId, fmt.Sprintf(undoDislikeText, int(undoWindow.Seconds())))
+	message.DisableNotification = true
+	message.ReplyMarkup = reasonsKeyboard(offerId)
+
+	msg, err := b.Send(message)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendUndoDislike.Send] error:", err)
+		return
+	}
+
+	time.AfterFunc(undoWindow, func() {
+		// the message is already deleted if the dislike was canceled
+		_, _ = b.bot.DeleteMessage(tgbotapi.NewDeleteMessage(chatId, msg.MessageID))
+	})
+}
+
+// dislikedCommand - /disliked shows the last disliked offers
+func (b *Bot) dislikedCommand(ctx context.Context, req *Request) {
+	b.sendDisliked(ctx, req.Chat.ID, 0)
+}
+
+// undoDislikeCallback - `undo:offerId` from the message after the dislike and
+//  `undo:offerId:list` from /disliked. Removes the dislike and sends the card
+//  again.
+func (b *Bot) undoDislikeCallback(ctx context.Context, req *Request) {
+	parts := strings.Split(req.Callback.Data, ":")
+	if len(parts) < 2 {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	offerId, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	chatId := req.Chat.ID
+	offer, err := b.storage.ReadOffer(ctx, offerId)
+	if err != nil {
+		b.SendError("undoDislikeCallback.ReadOffer", err, chatId)
+		return
+	}
+
+	text := restoredText
+	if offer == nil {
+		text = offerNotFoundText
+	} else {
+		err = b.storage.Undislike(ctx, offerId, chatId)
+		if err != nil {
+			b.SendError("undoDislikeCallback.Undislike", err, chatId)
+			return
+		}
+
+		err = b.SendOffer(ctx, offer, &structs.Chat{Id: chatId, Type: req.Chat.Type})
+		if err != nil {
+			b.SendError("undoDislikeCallback.SendOffer", err, chatId)
+			return
+		}
+	}
+
+	_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(req.Callback.ID, text))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[undoDislikeCallback.AnswerCallbackQuery] error:", err)
+	}
+
+	if len(parts) == 3 && parts[2] == "list" {
+		b.sendDisliked(ctx, chatId, req.Message.MessageID)
+		return
+	}
+
+	_, err = b.bot.DeleteMessage(tgbotapi.NewDeleteMessage(chatId, req.Message.MessageID))
+	if err != nil {
+		log.Println("[undoDislikeCallback.DeleteMessage] error:", err)
+	}
+}
+
+// sendDisliked - sends the list of the last dislikes or shows it in the
+//  message
+func (b *Bot) sendDisliked(ctx context.Context, chatId int64, messageId int) {
+	offers, err := b.storage.ReadDisliked(ctx, chatId, dislikedLimit)
+	if err != nil {
+		b.SendError("sendDisliked.ReadDisliked", err, chatId)
+		return
+	}
+
+	var text strings.Builder
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(offers))
+	if len(offers) == 0 {
+		text.WriteString(dislikedEmptyText)
+	} else {
+		text.WriteString(dislikedTitleText)
+	}
+
+	for i, offer := range offers {
+		text.WriteString(fmt.Sprintf("%d. %s\n%s\n\n", i+1, offer.Topic, offer.Url))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d. Вернуть", i+1), fmt.Sprintf("undo:%d:list", offer.Id)),
+		))
+	}
+
+	err = b.sendList(chatId, messageId, text.String(), rows)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendDisliked.Send] error:", err)
+	}
+}
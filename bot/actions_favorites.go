@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// favoritesPageSize - offers on one page of /favorites
+const favoritesPageSize = 5
+
+// favoritesCommand - /favorites shows the first page of favorites
+func (b *Bot) favoritesCommand(ctx context.Context, req *Request) {
+	b.sendFavorites(ctx, req.Chat.ID, 0, 0)
+}
+
+// favoritesCallback - buttons of /favorites: `fav:page:N` turns the page,
+//  `fav:open:offerId` sends the card again and `fav:del:offerId:N` removes
+//  the offer from favorites and shows the page N
+func (b *Bot) favoritesCallback(ctx context.Context, req *Request) {
+	parts := strings.Split(req.Callback.Data, ":")
+	if len(parts) < 3 {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	value, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	chatId := req.Chat.ID
+	switch {
+	case parts[1] == "page":
+		b.sendFavorites(ctx, chatId, int(value), req.Message.MessageID)
+	case parts[1] == "open":
+		b.openFavorite(ctx, req, value)
+	case parts[1] == "del" && len(parts) == 4:
+		err = b.storage.Unlike(ctx, value, chatId)
+		if err != nil {
+			b.SendError("favoritesCallback.Unlike", err, chatId)
+			return
+		}
+
+		page, _ := strconv.Atoi(parts[3])
+		b.sendFavorites(ctx, chatId, page, req.Message.MessageID)
+		_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(req.Callback.ID, unlikedText))
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[favoritesCallback.AnswerCallbackQuery] error:", err)
+		}
+	default:
+		b.unknownCallback(ctx, req)
+	}
+}
+
+// openFavorite - sends the card of the offer as a new message
+func (b *Bot) openFavorite(ctx context.Context, req *Request, offerId uint64) {
+	offer, err := b.storage.ReadOffer(ctx, offerId)
+	if err != nil {
+		b.SendError("openFavorite.ReadOffer", err, req.Chat.ID)
+		return
+	}
+
+	if offer == nil {
+		_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(req.Callback.ID, offerNotFoundText))
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[openFavorite.AnswerCallbackQuery] error:", err)
+		}
+		return
+	}
+
+	offer.Liked, err = b.storage.IsLiked(ctx, offerId, req.Chat.ID)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[openFavorite.IsLiked] error:", err)
+	}
+
+	offer.Status, err = b.storage.ReadOfferStatus(ctx, req.Chat.ID, offerId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[openFavorite.ReadOfferStatus] error:", err)
+	}
+
+	notes, err := b.storage.ReadNotes(ctx, req.Chat.ID, offerId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[openFavorite.ReadNotes] error:", err)
+	}
+	offer.Notes = notes[offerId]
+
+	err = b.SendOffer(ctx, offer, &structs.Chat{Id: req.Chat.ID, Type: req.Chat.Type})
+	if err != nil {
+		b.SendError("openFavorite.SendOffer", err, req.Chat.ID)
+	}
+}
+
+// sendFavorites - sends the page of favorites or shows it in the message
+func (b *Bot) sendFavorites(ctx context.Context, chatId int64, page, messageId int) {
+	offers, total, err := b.storage.ReadFavorites(ctx, chatId, page*favoritesPageSize, favoritesPageSize)
+	if err != nil {
+		b.SendError("sendFavorites.ReadFavorites", err, chatId)
+		return
+	}
+
+	// the last offer of the page was removed
+	if len(offers) == 0 && page > 0 {
+		b.sendFavorites(ctx, chatId, page-1, messageId)
+		return
+	}
+
+	ids := make([]uint64, 0, len(offers))
+	for _, offer := range offers {
+		ids = append(ids, offer.Id)
+	}
+
+	notes, err := b.storage.ReadNotes(ctx, chatId, ids...)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendFavorites.ReadNotes] error:", err)
+	}
+
+	var text strings.Builder
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(offers)+1)
+	if len(offers) == 0 {
+		text.WriteString(favoritesEmptyText)
+	} else {
+		text.WriteString(fmt.Sprintf(favoritesTitleText, total))
+	}
+
+	for i, offer := range offers {
+		n := page*favoritesPageSize + i + 1
+		text.WriteString(fmt.Sprintf("%d. %s\n", n, offer.Topic))
+		if offer.FullPrice != "" {
+			text.WriteString(offer.FullPrice)
+			text.WriteString("\n")
+		}
+		text.WriteString(offer.Url)
+		text.WriteString("\n")
+		if len(notes[offer.Id]) != 0 {
+			text.WriteString(cardNotesText(notes[offer.Id]))
+			text.WriteString("\n")
+		}
+		text.WriteString("\n")
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d. Открыть", n), fmt.Sprintf("fav:open:%d", offer.Id)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d. Убрать", n), fmt.Sprintf("fav:del:%d:%d", offer.Id, page)),
+		))
+	}
+
+	navigation := tgbotapi.NewInlineKeyboardRow()
+	if page > 0 {
+		navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("« Назад", fmt.Sprintf("fav:page:%d", page-1)))
+	}
+	if (page+1)*favoritesPageSize < total {
+		navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("Дальше »", fmt.Sprintf("fav:page:%d", page+1)))
+	}
+	if len(navigation) != 0 {
+		rows = append(rows, navigation)
+	}
+
+	err = b.sendList(chatId, messageId, text.String(), rows)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendFavorites.Send] error:", err)
+	}
+}
@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// marketLimit - how many lines /market shows, the districts with more offers
+//  go first
+const marketLimit = 30
+
+// marketCommand - /market shows the median prices by districts for the
+//  currencies and rooms of the chat filters
+func (b *Bot) marketCommand(ctx context.Context, req *Request) {
+	chat, err := b.storage.ReadChat(ctx, req.Chat.ID)
+	if err != nil {
+		b.SendError("marketCommand.ReadChat", err, req.Chat.ID)
+		return
+	}
+
+	stats, err := b.storage.ReadMarketStats(ctx, chat.Filters)
+	if err != nil {
+		b.SendError("marketCommand.ReadMarketStats", err, req.Chat.ID)
+		return
+	}
+
+	if len(stats) == 0 {
+		b.sendText(req.Chat.ID, marketEmptyText)
+		return
+	}
+
+	if len(stats) > marketLimit {
+		stats = stats[:marketLimit]
+	}
+	b.sendText(req.Chat.ID, marketStatsText(stats, b.marketDays))
+}
+
+// marketStatsText - the prices grouped by currency and rooms
+func marketStatsText(stats []*structs.MarketStat, days int) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf(marketTitleText, days))
+
+	group := ""
+	for _, stat := range stats {
+		currency := strings.ToUpper(stat.Currency)
+		title := fmt.Sprintf("\n%d-комн., %s:\n", stat.Rooms, currency)
+		if title != group {
+			group = title
+			text.WriteString(title)
+		}
+
+		place := stat.District
+		if stat.City != "" {
+			place = stat.City + ", " + stat.District
+		}
+
+		text.WriteString(fmt.Sprintf(
+			"%s: %d (%d - %d), объявлений: %d\n",
+			place,
+			stat.Median,
+			stat.P25,
+			stat.P75,
+			stat.Samples,
+		))
+	}
+	return text.String()
+}
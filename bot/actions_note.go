@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/bot/fsm"
+	"github.com/comov/hsearch/structs"
+)
+
+// noteWait - how long the bot waits for the note
+const noteWait = time.Minute * 5
+
+// noteCallback - "Заметка" under the card, the next message of the chat is
+//  the note to the offer
+func (b *Bot) noteCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	offerId, err := b.queryOfferId(ctx, query)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[noteCallback.queryOfferId] error:", err)
+		return
+	}
+
+	message := tgbotapi.NewMessage(chatId, noteAskText)
+	message.ReplyToMessageID = query.Message.MessageID
+	_, err = b.Send(message)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[noteCallback.Send] error:", err)
+		return
+	}
+
+	err = b.startDialog(ctx, chatId, stateNote, 0, map[string]string{
+		"offer": strconv.FormatUint(offerId, 10),
+		"card":  strconv.Itoa(query.Message.MessageID),
+	})
+	if err != nil {
+		b.SendError("noteCallback.startDialog", err, chatId)
+	}
+}
+
+// noteWaiterCallback - saves the message as the note and shows it in the card
+func (b *Bot) noteWaiterCallback(ctx context.Context, message *tgbotapi.Message, state *fsm.State) (string, error) {
+	offerId, err := strconv.ParseUint(state.Data["offer"], 10, 64)
+	if err != nil {
+		return fsm.Done, err
+	}
+
+	body := strings.TrimSpace(message.Text)
+	if body == "" {
+		return stateNote, fsm.ErrWrongAnswer
+	}
+
+	err = b.storage.AddNote(ctx, message.Chat.ID, offerId, &structs.Note{
+		User:     int64(message.From.ID),
+		Username: voterName(message.From),
+		Body:     body,
+	})
+	if err != nil {
+		return fsm.Done, err
+	}
+
+	b.sendText(message.Chat.ID, noteSavedText)
+
+	card, _ := strconv.Atoi(state.Data["card"])
+	err = b.refreshCard(ctx, message.Chat, card, offerId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[noteWaiterCallback.refreshCard] error:", err)
+	}
+	return fsm.Done, nil
+}
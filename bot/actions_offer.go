@@ -11,100 +11,275 @@ import (
 	"github.com/comov/hsearch/structs"
 )
 
-var (
-	dislikeButton     = tgbotapi.NewInlineKeyboardButtonData("Точно нет!", "dislike")
-	descriptionButton = tgbotapi.NewInlineKeyboardButtonData("Описание", "description")
-)
+// getKeyboard - the buttons under the card. In groups everyone votes 👍 or
+//  👎 and the offer is hidden by the vote rule of the chat, so there is no
+//  "Точно нет!".
+func getKeyboard(offer *structs.Offer, group bool) tgbotapi.InlineKeyboardMarkup {
+	like := tgbotapi.NewInlineKeyboardButtonData("Нравится", offerCallbackData("like", offer))
+	if offer.Liked {
+		like = tgbotapi.NewInlineKeyboardButtonData("Из избранного", offerCallbackData("unlike", offer))
+	}
 
-func getKeyboard(offer *structs.Offer) tgbotapi.InlineKeyboardMarkup {
-	row1 := tgbotapi.NewInlineKeyboardRow(dislikeButton)
+	row1 := tgbotapi.NewInlineKeyboardRow(
+		like,
+		tgbotapi.NewInlineKeyboardButtonData("Точно нет!", offerCallbackData("dislike", offer)),
+	)
+	if group {
+		up, down := countVotes(offer.Votes)
+		row1 = tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("👍 %d", up), offerCallbackData("up", offer)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("👎 %d", down), offerCallbackData("down", offer)),
+			like,
+		)
+	}
 	row2 := tgbotapi.NewInlineKeyboardRow()
 
 	if len(offer.Body) != 0 {
-		row2 = append(row2, descriptionButton)
+		row2 = append(row2, tgbotapi.NewInlineKeyboardButtonData("Описание", offerCallbackData("description", offer)))
 	}
 
 	if offer.Images != 0 {
-		row2 = append(row2, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("Фото (%d)", offer.Images), "photo"))
+		row2 = append(row2, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("Фото (%d)", offer.Images),
+			offerCallbackData("photo", offer),
+		))
 	}
 
-	if len(row2) == 0 {
-		return tgbotapi.NewInlineKeyboardMarkup(row1)
+	row2 = append(row2, tgbotapi.NewInlineKeyboardButtonData("📝 Заметка", offerCallbackData("note", offer)))
+
+	row3 := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("📌 Статус: "+statusName(offer.Status), offerCallbackData("status", offer)),
+		tgbotapi.NewInlineKeyboardButtonData("📅 Назначить просмотр", offerCallbackData("viewing", offer)),
+	)
+	row4 := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⏰ Напомнить позже", offerCallbackData("snooze", offer)),
+		tgbotapi.NewInlineKeyboardButtonData("⚠️ Сообщить об ошибке", offerCallbackData("report", offer)),
+	)
+
+	if offer.Phone != "" {
+		row5 := tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🚫 Не показывать от этого номера", offerCallbackData("block", offer)),
+		)
+		return tgbotapi.NewInlineKeyboardMarkup(row1, row2, row3, row4, row5)
 	}
 
-	return tgbotapi.NewInlineKeyboardMarkup(row1, row2)
+	return tgbotapi.NewInlineKeyboardMarkup(row1, row2, row3, row4)
+}
+
+// refreshCard - shows the current state of the offer in the card: votes,
+//  favorites, the status and the notes. The card keeps the name of the search
+//  it was sent for.
+func (b *Bot) refreshCard(ctx context.Context, chat *tgbotapi.Chat, messageId int, offerId uint64) error {
+	chatId := chat.ID
+	offer, err := b.storage.ReadOffer(ctx, offerId)
+	if err != nil {
+		return err
+	}
+	if offer == nil {
+		return nil
+	}
+
+	offer.Profile, err = b.storage.ReadMessageProfile(ctx, messageId, chatId)
+	if err != nil {
+		return err
+	}
+
+	offer.Liked, err = b.storage.IsLiked(ctx, offerId, chatId)
+	if err != nil {
+		return err
+	}
+
+	offer.Status, err = b.storage.ReadOfferStatus(ctx, chatId, offerId)
+	if err != nil {
+		return err
+	}
+
+	notes, err := b.storage.ReadNotes(ctx, chatId, offerId)
+	if err != nil {
+		return err
+	}
+	offer.Notes = notes[offerId]
+
+	group := chat.IsGroup() || chat.IsSuperGroup()
+	if group {
+		offer.Votes, err = b.storage.ReadVotes(ctx, chatId, offerId)
+		if err != nil {
+			return err
+		}
+	}
+
+	keyboard := getKeyboard(offer, group)
+	edit := tgbotapi.NewEditMessageText(chatId, messageId, DefaultMessage(offer))
+	edit.DisableWebPagePreview = true
+	edit.ParseMode = tgbotapi.ModeMarkdown
+	edit.ReplyMarkup = &keyboard
+	_, err = b.Send(edit)
+	return err
 }
 
 // dislike - this button delete order from chat and no more show to user that order
 func (b *Bot) dislike(ctx context.Context, query *tgbotapi.CallbackQuery) {
-	messagesIds, err := b.storage.Dislike(
-		ctx,
-		query.Message.MessageID,
-		query.Message.Chat.ID,
-	)
+	offerId, err := b.queryOfferId(ctx, query)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[dislike.queryOfferId] error:", err)
+		return
+	}
+
+	err = b.hideOffer(ctx, offerId, query.Message.Chat.ID)
 	if err != nil {
 		sentry.CaptureException(err)
-		log.Println("[dislike.Dislike] error:", err)
+		log.Println("[dislike.hideOffer] error:", err)
 		return
 	}
 
+	_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(
+		query.ID, "Больше никогда не покажу",
+	))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[dislike.AnswerCallbackQuery] error:", err)
+	}
+}
+
+// hideOffer - saves the dislike, deletes the card with the photos and the
+//  description from the chat and offers to cancel it
+func (b *Bot) hideOffer(ctx context.Context, offerId uint64, chatId int64) error {
+	err := b.removeOffer(ctx, offerId, chatId)
+	if err != nil {
+		return err
+	}
+
+	b.sendUndoDislike(chatId, offerId)
+	return nil
+}
+
+// removeOffer - saves the dislike and deletes the card with the photos and
+//  the description from the chat
+func (b *Bot) removeOffer(ctx context.Context, offerId uint64, chatId int64) error {
+	messagesIds, err := b.storage.Dislike(ctx, offerId, chatId)
+	if err != nil {
+		return err
+	}
+
 	for _, id := range messagesIds {
 		_, err := b.bot.DeleteMessage(
-			tgbotapi.NewDeleteMessage(query.Message.Chat.ID, id),
+			tgbotapi.NewDeleteMessage(chatId, id),
 		)
 		if err != nil {
 			sentry.CaptureException(err)
-			log.Println("[dislike.DeleteMessage] error:", err)
+			log.Println("[removeOffer.DeleteMessage] error:", err)
 		}
 	}
+	return nil
+}
 
-	_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(
-		query.ID, "Больше никогда не покажу",
-	))
+// like - adds the offer to favorites and changes the button under the card
+func (b *Bot) like(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	b.setLiked(ctx, query, true)
+}
+
+// unlike - removes the offer from favorites
+func (b *Bot) unlike(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	b.setLiked(ctx, query, false)
+}
+
+func (b *Bot) setLiked(ctx context.Context, query *tgbotapi.CallbackQuery, liked bool) {
+	offerId, err := b.queryOfferId(ctx, query)
 	if err != nil {
 		sentry.CaptureException(err)
-		log.Println("[dislike.AnswerCallbackQuery] error:", err)
+		log.Println("[setLiked.queryOfferId] error:", err)
+		return
+	}
+
+	text := likedText
+	if liked {
+		err = b.storage.Like(ctx, offerId, query.Message.Chat.ID)
+	} else {
+		text = unlikedText
+		err = b.storage.Unlike(ctx, offerId, query.Message.Chat.ID)
+	}
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[setLiked.Like] error:", err)
+		return
+	}
+
+	_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, text))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[setLiked.AnswerCallbackQuery] error:", err)
+	}
+
+	err = b.refreshCard(ctx, query.Message.Chat, query.Message.MessageID, offerId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[setLiked.refreshCard] error:", err)
 	}
 }
 
 // description - return full description about order
 func (b *Bot) description(ctx context.Context, query *tgbotapi.CallbackQuery) {
-	offerId, body, err := b.storage.ReadOfferDescription(
-		ctx,
-		query.Message.MessageID,
-		query.Message.Chat.ID,
-	)
+	offerId, err := b.queryOfferId(ctx, query)
 	if err != nil {
 		sentry.CaptureException(err)
-		log.Println("[description.ReadOfferDescription] error:", err)
+		log.Println("[description.queryOfferId] error:", err)
 		return
 	}
 
-	message := tgbotapi.NewMessage(query.Message.Chat.ID, body)
-	message.ReplyToMessageID = query.Message.MessageID
-
-	send, err := b.Send(message)
+	body, err := b.storage.ReadOfferDescription(ctx, offerId)
 	if err != nil {
 		sentry.CaptureException(err)
-		log.Println("[description.Send] error:", err)
+		log.Println("[description.ReadOfferDescription] error:", err)
+		return
 	}
 
-	err = b.storage.SaveMessage(
-		ctx,
-		send.MessageID,
-		offerId,
-		query.Message.Chat.ID,
-		structs.KindDescription,
-	)
+	notes, err := b.storage.ReadNotes(ctx, query.Message.Chat.ID, offerId)
 	if err != nil {
 		sentry.CaptureException(err)
-		log.Println("[photo.SaveMessage] error:", err)
+		log.Println("[description.ReadNotes] error:", err)
+	}
+	if len(notes[offerId]) != 0 {
+		body += "\n\n" + notesText(notes[offerId])
+	}
+
+	// the description with all the notes can be longer than one message
+	for _, text := range splitMessage(body) {
+		message := tgbotapi.NewMessage(query.Message.Chat.ID, text)
+		message.ReplyToMessageID = query.Message.MessageID
+
+		send, err := b.Send(message)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[description.Send] error:", err)
+			return
+		}
+
+		err = b.storage.SaveMessage(
+			ctx,
+			send.MessageID,
+			offerId,
+			query.Message.Chat.ID,
+			structs.KindDescription,
+			"",
+		)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[description.SaveMessage] error:", err)
+		}
 	}
 }
 
 // photo - this button return all orders photos from site
 func (b *Bot) photo(ctx context.Context, query *tgbotapi.CallbackQuery) {
-	offerId, images, err := b.storage.ReadOfferImages(ctx, query.Message.MessageID, query.Message.Chat.ID)
+	offerId, err := b.queryOfferId(ctx, query)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[photo.queryOfferId] error:", err)
+		return
+	}
+
+	images, err := b.storage.ReadOfferImages(ctx, offerId)
 	if err != nil {
 		sentry.CaptureException(err)
 		log.Println("[photo.ReadOfferDescription] error:", err)
@@ -120,16 +295,10 @@ func (b *Bot) photo(ctx context.Context, query *tgbotapi.CallbackQuery) {
 		}
 	}
 
+	failed := 0
 	for _, album := range getSeparatedAlbums(images) {
-		imgs := make([]interface{}, 0)
-		for _, img := range album {
-			imgs = append(imgs, tgbotapi.NewInputMediaPhoto(img))
-		}
-
-		message := tgbotapi.NewMediaGroup(query.Message.Chat.ID, imgs)
-		message.ReplyToMessageID = query.Message.MessageID
-
-		messages, err := b.SendGroupPhotos(message)
+		messages, notSent, err := b.sendAlbum(ctx, query.Message.Chat.ID, query.Message.MessageID, album)
+		failed += notSent
 		if err != nil {
 			sentry.CaptureException(err)
 			log.Println("[photo.Send] sending album error:", err)
@@ -142,6 +311,7 @@ func (b *Bot) photo(ctx context.Context, query *tgbotapi.CallbackQuery) {
 				offerId,
 				query.Message.Chat.ID,
 				structs.KindPhoto,
+				"",
 			)
 			if err != nil {
 				sentry.CaptureException(err)
@@ -158,13 +328,23 @@ func (b *Bot) photo(ctx context.Context, query *tgbotapi.CallbackQuery) {
 			log.Println("[photo.DeleteMessage] error:", err)
 		}
 	}
+
+	if failed != 0 && query.Message.Chat.Type != "channel" {
+		message := tgbotapi.NewMessage(query.Message.Chat.ID, fmt.Sprintf(photosFailedText, failed))
+		message.ReplyToMessageID = query.Message.MessageID
+		_, err := b.Send(message)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[photo.Send] failed photos error:", err)
+		}
+	}
 }
 
 // getSeparatedAlbums - separate images array to 10-items albums. Telegram API
 //  has limit: `max images in images album is 10`
-func getSeparatedAlbums(images []string) [][]string {
+func getSeparatedAlbums(images []*structs.Image) [][]*structs.Image {
 	maxImages := 10
-	albums := make([][]string, 0, (len(images)+maxImages-1)/maxImages)
+	albums := make([][]*structs.Image, 0, (len(images)+maxImages-1)/maxImages)
 
 	for maxImages < len(images) {
 		images, albums = images[maxImages:], append(albums, images[0:maxImages:maxImages])
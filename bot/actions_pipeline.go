@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// pipelineLimit - how many offers of one status /pipeline shows
+const pipelineLimit = 10
+
+// statusMenu - replaces the buttons of the card with the statuses
+func (b *Bot) statusMenu(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	offerId, err := b.queryOfferId(ctx, query)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[statusMenu.queryOfferId] error:", err)
+		return
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(structs.Statuses)/2+1)
+	row := tgbotapi.NewInlineKeyboardRow()
+	for _, status := range structs.Statuses {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			statusName(status),
+			fmt.Sprintf("st:%d:%s", offerId, status),
+		))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = tgbotapi.NewInlineKeyboardRow()
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("« Назад", fmt.Sprintf("st:%d:", offerId)),
+	))
+
+	_, err = b.Send(tgbotapi.NewEditMessageReplyMarkup(
+		query.Message.Chat.ID,
+		query.Message.MessageID,
+		tgbotapi.NewInlineKeyboardMarkup(rows...),
+	))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[statusMenu.Send] error:", err)
+	}
+}
+
+// statusCallback - `st:offerId:status` from the status menu of the card,
+//  without the status it just returns the buttons of the card
+func (b *Bot) statusCallback(ctx context.Context, req *Request) {
+	parts := strings.Split(req.Callback.Data, ":")
+	if len(parts) != 3 {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	offerId, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	status := parts[2]
+	if status != "" {
+		if !validStatus(status) {
+			b.unknownCallback(ctx, req)
+			return
+		}
+
+		err = b.storage.SetOfferStatus(ctx, req.Chat.ID, offerId, status)
+		if err != nil {
+			b.SendError("statusCallback.SetOfferStatus", err, req.Chat.ID)
+			return
+		}
+
+		_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(
+			req.Callback.ID,
+			fmt.Sprintf(statusSavedText, statusName(status)),
+		))
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[statusCallback.AnswerCallbackQuery] error:", err)
+		}
+	}
+
+	err = b.refreshCard(ctx, req.Message.Chat, req.Message.MessageID, offerId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[statusCallback.refreshCard] error:", err)
+	}
+}
+
+// pipelineCommand - /pipeline shows the offers of the chat grouped by status
+func (b *Bot) pipelineCommand(ctx context.Context, req *Request) {
+	offers, err := b.storage.ReadPipeline(ctx, req.Chat.ID)
+	if err != nil {
+		b.SendError("pipelineCommand.ReadPipeline", err, req.Chat.ID)
+		return
+	}
+
+	if len(offers) == 0 {
+		b.sendText(req.Chat.ID, pipelineEmptyText)
+		return
+	}
+
+	byStatus := make(map[string][]*structs.Offer)
+	for _, offer := range offers {
+		byStatus[offer.Status] = append(byStatus[offer.Status], offer)
+	}
+
+	var text strings.Builder
+	text.WriteString(pipelineTitleText)
+	for _, status := range structs.Statuses {
+		group := byStatus[status]
+		if len(group) == 0 {
+			continue
+		}
+
+		text.WriteString(fmt.Sprintf("\n📌 %s (%d):\n", statusName(status), len(group)))
+		for i, offer := range group {
+			if i == pipelineLimit {
+				text.WriteString("...\n")
+				break
+			}
+			text.WriteString(fmt.Sprintf("%d. %s\n%s\n", i+1, offer.Topic, offer.Url))
+		}
+	}
+
+	err = b.sendList(req.Chat.ID, 0, text.String(), nil)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[pipelineCommand.Send] error:", err)
+	}
+}
+
+func validStatus(status string) bool {
+	for _, s := range structs.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/bot/input"
+	"github.com/comov/hsearch/structs"
+)
+
+// profileName - one word of letters, digits and dashes. The name is shown in
+//  the offer card, which is sent in markdown, so no special symbols.
+var profileName = regexp.MustCompile(`^[\p{L}\d-]{1,50}$`)
+
+// profilesCommand - /profiles shows the searches of the chat
+func (b *Bot) profilesCommand(ctx context.Context, req *Request) {
+	b.sendProfiles(ctx, req.Chat.ID, 0)
+}
+
+// profileCommand - `/profile name filters` creates or changes the search,
+//  `/profile name` shows it and `/profile name delete` deletes it
+func (b *Bot) profileCommand(ctx context.Context, req *Request) {
+	args := strings.Fields(req.Message.CommandArguments())
+	if len(args) == 0 {
+		b.sendProfiles(ctx, req.Chat.ID, 0)
+		return
+	}
+
+	name := args[0]
+	if !profileName.MatchString(name) {
+		b.sendText(req.Chat.ID, profileWrongNameText)
+		return
+	}
+
+	profiles, err := b.storage.ReadSearchProfiles(ctx, req.Chat.ID)
+	if err != nil {
+		b.SendError("profileCommand.ReadSearchProfiles", err, req.Chat.ID)
+		return
+	}
+
+	var profile *structs.SearchProfile
+	for _, p := range profiles {
+		if p.Name == name {
+			profile = p
+		}
+	}
+
+	rest := strings.Join(args[1:], " ")
+	switch {
+	case rest == "" || rest == "delete" || rest == "удалить":
+		if profile == nil {
+			b.sendText(req.Chat.ID, fmt.Sprintf(profileNotFoundText, name))
+			return
+		}
+
+		if rest == "" {
+			b.sendText(req.Chat.ID, profileText(profile))
+			return
+		}
+
+		err = b.storage.DeleteSearchProfile(ctx, req.Chat.ID, profile.Id)
+		if err != nil {
+			b.SendError("profileCommand.DeleteSearchProfile", err, req.Chat.ID)
+			return
+		}
+		b.sendText(req.Chat.ID, fmt.Sprintf(profileDeletedText, name))
+		return
+	}
+
+	if profile == nil {
+		profile = &structs.SearchProfile{
+			Chat:   req.Chat.ID,
+			Name:   name,
+			Enable: true,
+			Filters: structs.Filters{
+				Diesel: true,
+				House:  true,
+				Lalafo: true,
+			},
+		}
+	}
+
+	err = input.ParseFilter(rest, &profile.Filters, &profile.Enable)
+	if err != nil {
+		log.Println("[profileCommand.ParseFilter] error:", err)
+		part := rest
+		if fe, ok := err.(*input.FilterError); ok {
+			part = fe.Part
+		}
+		b.sendText(req.Chat.ID, fmt.Sprintf(filterWrongText, part, "/profile "+name+" "+input.FilterSyntax))
+		return
+	}
+
+	err = b.storage.SaveSearchProfile(ctx, profile)
+	if err != nil {
+		b.SendError("profileCommand.SaveSearchProfile", err, req.Chat.ID)
+		return
+	}
+	b.sendText(req.Chat.ID, profileText(profile))
+}
+
+// profileCallback - buttons under the list of searches: `profile:on:id`,
+//  `profile:off:id` and `profile:del:id`
+func (b *Bot) profileCallback(ctx context.Context, req *Request) {
+	chatId := req.Chat.ID
+	parts := strings.Split(req.Callback.Data, ":")
+	if len(parts) != 3 {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	switch parts[1] {
+	case "on", "off":
+		err = b.storage.EnableSearchProfile(ctx, chatId, id, parts[1] == "on")
+	case "del":
+		err = b.storage.DeleteSearchProfile(ctx, chatId, id)
+	default:
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	if err != nil {
+		b.SendError("profileCallback", err, chatId)
+		return
+	}
+
+	b.sendProfiles(ctx, chatId, req.Message.MessageID)
+}
+
+// sendProfiles - sends the list of searches or updates it in the message
+func (b *Bot) sendProfiles(ctx context.Context, chatId int64, messageId int) {
+	profiles, err := b.storage.ReadSearchProfiles(ctx, chatId)
+	if err != nil {
+		b.SendError("sendProfiles.ReadSearchProfiles", err, chatId)
+		return
+	}
+
+	texts := make([]string, 0, len(profiles))
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(profiles))
+	enabled := 0
+	for _, profile := range profiles {
+		texts = append(texts, profileText(profile))
+		if profile.Enable {
+			enabled++
+		}
+
+		toggle := tgbotapi.NewInlineKeyboardButtonData("▶️ "+profile.Name, fmt.Sprintf("profile:on:%d", profile.Id))
+		if profile.Enable {
+			toggle = tgbotapi.NewInlineKeyboardButtonData("⏸ "+profile.Name, fmt.Sprintf("profile:off:%d", profile.Id))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			toggle,
+			tgbotapi.NewInlineKeyboardButtonData("🗑", fmt.Sprintf("profile:del:%d", profile.Id)),
+		))
+	}
+
+	text := strings.Join(texts, "\n\n")
+	if len(profiles) == 0 {
+		text = profilesEmptyText
+	} else if enabled == 0 {
+		text += "\n\n" + profilesDisabledText
+	}
+
+	err = b.sendList(chatId, messageId, text, rows)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendProfiles.Send] error:", err)
+	}
+}
+
+// sendText - sends the plain text to the chat
+func (b *Bot) sendText(chatId int64, text string) {
+	_, err := b.Send(tgbotapi.NewMessage(chatId, text))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendText.Send] error:", err)
+	}
+}
+
+// profileText - the search with its filters in the command syntax
+func profileText(profile *structs.SearchProfile) string {
+	status := "включен"
+	if !profile.Enable {
+		status = "выключен"
+	}
+	return fmt.Sprintf(
+		"%s (%s)\n/profile %s %s",
+		profile.Name,
+		status,
+		profile.Name,
+		input.FormatFilter(profile.Filters, profile.Enable),
+	)
+}
@@ -0,0 +1,303 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/bot/input"
+	"github.com/comov/hsearch/structs"
+)
+
+// reasons of the dislike. There are no filters by the district and the
+//  floor, so there is nothing to propose for them and they are not asked.
+const (
+	reasonPrice  = "price"
+	reasonAgency = "agency"
+	reasonPhoto  = "photo"
+)
+
+// suggestThreshold - after so many dislikes with the same reason the bot
+//  proposes to change the filter, and again after the same number
+const suggestThreshold = 3
+
+// reasonButtons - the reasons in the order of the buttons
+var reasonButtons = []struct {
+	reason string
+	text   string
+}{
+	{reasonPrice, "Дорого"},
+	{reasonAgency, "Агентство"},
+	{reasonPhoto, "Мало фото"},
+}
+
+// reasonsKeyboard - `reason:code:offerId` buttons and the undo button
+func reasonsKeyboard(offerId uint64) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, 3)
+	row := tgbotapi.NewInlineKeyboardRow()
+	for _, r := range reasonButtons {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(r.text, fmt.Sprintf("reason:%s:%d", r.reason, offerId)))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = tgbotapi.NewInlineKeyboardRow()
+		}
+	}
+	if len(row) != 0 {
+		rows = append(rows, row)
+	}
+
+	rows = append(rows, undoRow(offerId))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// undoRow - the button to cancel the dislike, it stays after the reason is
+//  chosen
+func undoRow(offerId uint64) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Отменить", fmt.Sprintf("undo:%d", offerId)),
+	)
+}
+
+// reasonCallback - saves the reason of the dislike and, if the chat often
+//  dislikes for this reason, proposes to change the filter
+func (b *Bot) reasonCallback(ctx context.Context, req *Request) {
+	parts := strings.Split(req.Callback.Data, ":")
+	if len(parts) != 3 {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	offerId, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	chatId, reason := req.Chat.ID, parts[1]
+	err = b.storage.SetDislikeReason(ctx, offerId, chatId, reason)
+	if err != nil {
+		b.SendError("reasonCallback.SetDislikeReason", err, chatId)
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatId, req.Message.MessageID, reasonSavedText)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(undoRow(offerId))
+	edit.ReplyMarkup = &keyboard
+	_, err = b.Send(edit)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[reasonCallback.Send] error:", err)
+	}
+
+	b.suggestFilter(ctx, chatId, reason)
+}
+
+// suggestFilter - proposes the filter which would hide the offers disliked
+//  for the reason. Only the chat filters are changed, so there is nothing to
+//  propose about the price and photos if the chat searches by profiles. The
+//  phones of agencies go to the blacklist of the chat, which works for all
+//  searches.
+func (b *Bot) suggestFilter(ctx context.Context, chatId int64, reason string) {
+	offers, err := b.storage.ReadDislikedByReason(ctx, chatId, reason)
+	if err != nil || len(offers) == 0 || len(offers)%suggestThreshold != 0 {
+		return
+	}
+
+	if reason == reasonAgency {
+		b.suggestBlacklist(chatId, offers)
+		return
+	}
+
+	profiles, err := b.storage.ReadSearchProfiles(ctx, chatId)
+	if err != nil || len(profiles) != 0 {
+		return
+	}
+
+	chat, err := b.storage.ReadChat(ctx, chatId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[suggestFilter.ReadChat] error:", err)
+		return
+	}
+
+	text, data := "", ""
+	switch reason {
+	case reasonPrice:
+		currency, maxPrice := suggestMaxPrice(chat, offers)
+		if maxPrice == 0 {
+			return
+		}
+		text = fmt.Sprintf(suggestPriceText, len(offers), currency, maxPrice)
+		data = fmt.Sprintf("suggest:price:%s:%d", currency, maxPrice)
+	case reasonPhoto:
+		if chat.Photo {
+			return
+		}
+		text = fmt.Sprintf(suggestPhotoText, len(offers))
+		data = "suggest:photo"
+	}
+
+	b.sendSuggestion(chatId, text, data)
+}
+
+// suggestBlacklist - proposes to blacklist the phones of the offers disliked
+//  as agencies
+func (b *Bot) suggestBlacklist(chatId int64, offers []*structs.Offer) {
+	phones := make(map[string]bool)
+	for _, offer := range offers {
+		if offer.PhoneNorm != "" {
+			phones[offer.PhoneNorm] = true
+		}
+	}
+	if len(phones) == 0 {
+		return
+	}
+
+	b.sendSuggestion(chatId, fmt.Sprintf(suggestAgencyText, len(offers), len(phones)), "suggest:agency")
+}
+
+// sendSuggestion - sends the proposal with the yes and no buttons
+func (b *Bot) sendSuggestion(chatId int64, text, data string) {
+	message := tgbotapi.NewMessage(chatId, text)
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Да", data),
+		tgbotapi.NewInlineKeyboardButtonData("Нет", "suggest:no"),
+	))
+	_, err := b.Send(message)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendSuggestion.Send] error:", err)
+	}
+}
+
+// suggestMaxPrice - the upper bound below the cheapest "too expensive" offer
+//  in the currency with the most of such offers. Returns 0 if the chat filter
+//  is already lower.
+func suggestMaxPrice(chat *structs.Chat, offers []*structs.Offer) (string, int) {
+	count := make(map[string]int)
+	cheapest := make(map[string]int)
+	for _, offer := range offers {
+		if offer.Price <= 0 {
+			continue
+		}
+		count[offer.Currency]++
+		if p, ok := cheapest[offer.Currency]; !ok || offer.Price < p {
+			cheapest[offer.Currency] = offer.Price
+		}
+	}
+
+	currency := ""
+	for c, n := range count {
+		if currency == "" || n > count[currency] {
+			currency = c
+		}
+	}
+
+	step, filter := 10, chat.USD
+	switch currency {
+	case "usd":
+	case "kgs":
+		step, filter = 1000, chat.KGS
+	default:
+		return "", 0
+	}
+
+	maxPrice := (cheapest[currency] - 1) / step * step
+	if maxPrice <= 0 || filter[0] < 0 || (filter[1] != 0 && filter[1] <= maxPrice) {
+		return "", 0
+	}
+	return strings.ToUpper(currency), maxPrice
+}
+
+// suggestCallback - `suggest:price:USD:350` and `suggest:photo` apply the
+//  proposed filter, `suggest:agency` blacklists the phones of the agencies,
+//  `suggest:no` declines it
+func (b *Bot) suggestCallback(ctx context.Context, req *Request) {
+	chatId := req.Chat.ID
+	parts := strings.Split(req.Callback.Data, ":")
+	if len(parts) < 2 {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	text := suggestAppliedText
+	switch parts[1] {
+	case "no":
+		text = suggestDeclinedText
+	case "agency":
+		offers, err := b.storage.ReadDislikedByReason(ctx, chatId, reasonAgency)
+		if err != nil {
+			b.SendError("suggestCallback.ReadDislikedByReason", err, chatId)
+			return
+		}
+
+		for _, offer := range offers {
+			if offer.PhoneNorm == "" {
+				continue
+			}
+			_, err = b.storage.BlacklistOfferPhone(ctx, chatId, offer.Id, reasonAgency)
+			if err != nil {
+				b.SendError("suggestCallback.BlacklistOfferPhone", err, chatId)
+				return
+			}
+		}
+		text = suggestBlacklistedText
+	case "photo", "price":
+		chat, err := b.storage.ReadChat(ctx, chatId)
+		if err != nil {
+			b.SendError("suggestCallback.ReadChat", err, chatId)
+			return
+		}
+
+		if parts[1] == "photo" {
+			chat.Photo = true
+		} else {
+			if len(parts) != 4 {
+				b.unknownCallback(ctx, req)
+				return
+			}
+			maxPrice, err := strconv.Atoi(parts[3])
+			if err != nil {
+				b.unknownCallback(ctx, req)
+				return
+			}
+			setMaxPrice(&chat.Filters, parts[2], maxPrice)
+		}
+
+		err = b.storage.UpdateSettings(ctx, chat)
+		if err != nil {
+			b.SendError("suggestCallback.UpdateSettings", err, chatId)
+			return
+		}
+	default:
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	_, err := b.Send(tgbotapi.NewEditMessageText(chatId, req.Message.MessageID, text))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[suggestCallback.Send] error:", err)
+	}
+}
+
+// setMaxPrice - changes the upper bound of the price, the lower bound is kept
+//  if it is still lower
+func setMaxPrice(filters *structs.Filters, currency string, maxPrice int) {
+	price := filters.USD
+	if currency == input.KGS {
+		price = filters.KGS
+	}
+
+	from := price[0]
+	if from < 0 || from >= maxPrice {
+		from = 0
+	}
+
+	input.ApplyPrices(filters, []input.PriceRange{{Currency: currency, Price: structs.Price{from, maxPrice}}})
+}
@@ -0,0 +1,229 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// reportReasons - the categories of the report in the order of the buttons
+var reportReasons = []struct {
+	reason string
+	title  string
+}{
+	{structs.ReportPrice, "Неверная цена"},
+	{structs.ReportRented, "Уже сдано"},
+	{structs.ReportAgency, "Агентство, а не хозяин"},
+	{structs.ReportScam, "Мошенники"},
+}
+
+// reportMenu - replaces the buttons of the card with the categories of the
+//  report
+func (b *Bot) reportMenu(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	offerId, err := b.queryOfferId(ctx, query)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[reportMenu.queryOfferId] error:", err)
+		return
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(reportReasons)+1)
+	for _, r := range reportReasons {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(r.title, fmt.Sprintf("rp:%d:%s", offerId, r.reason)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("« Назад", fmt.Sprintf("rp:%d:", offerId)),
+	))
+
+	_, err = b.Send(tgbotapi.NewEditMessageReplyMarkup(
+		query.Message.Chat.ID,
+		query.Message.MessageID,
+		tgbotapi.NewInlineKeyboardMarkup(rows...),
+	))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[reportMenu.Send] error:", err)
+	}
+}
+
+// reportCallback - `rp:offerId:reason` saves the report and sends it to the
+//  admin chat, without the reason just returns the buttons of the card
+func (b *Bot) reportCallback(ctx context.Context, req *Request) {
+	chatId := req.Chat.ID
+	parts := strings.Split(req.Callback.Data, ":")
+	if len(parts) != 3 {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	offerId, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	if parts[2] != "" {
+		title := reportTitle(parts[2])
+		if title == "" {
+			b.unknownCallback(ctx, req)
+			return
+		}
+
+		report := &structs.Report{
+			Chat:     chatId,
+			User:     int64(req.Callback.From.ID),
+			Username: voterName(req.Callback.From),
+			OfferId:  offerId,
+			Reason:   parts[2],
+		}
+		count, err := b.storage.AddReport(ctx, report)
+		if err != nil {
+			b.SendError("reportCallback.AddReport", err, chatId)
+			return
+		}
+
+		_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(req.Callback.ID, reportSavedText))
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[reportCallback.AnswerCallbackQuery] error:", err)
+		}
+
+		// the report is already resolved, the admin has seen it
+		if count != 0 {
+			b.sendReport(ctx, report, count)
+		}
+	}
+
+	err = b.refreshCard(ctx, req.Message.Chat, req.Message.MessageID, offerId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[reportCallback.refreshCard] error:", err)
+	}
+}
+
+// sendReport - forwards the report to the admin chat with the buttons of the
+//  decision. The offer is hidden without the admin when there are too many
+//  reports.
+func (b *Bot) sendReport(ctx context.Context, report *structs.Report, count int) {
+	offer, err := b.storage.ReadOffer(ctx, report.OfferId)
+	if err != nil || offer == nil {
+		log.Println("[sendReport.ReadOffer] offer:", report.OfferId, "error:", err)
+		return
+	}
+
+	text := fmt.Sprintf(
+		reportAdminText,
+		reportTitle(report.Reason),
+		report.Username,
+		report.Chat,
+		count,
+		offer.Topic,
+		offer.Phone,
+		offer.Url,
+	)
+
+	if b.reportThreshold > 0 && count >= b.reportThreshold {
+		err = b.storage.HideOffer(ctx, offer.Id)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[sendReport.HideOffer] error:", err)
+		} else {
+			text += fmt.Sprintf(reportAutoHiddenText, count, b.reportThreshold)
+		}
+	}
+
+	if b.adminChatId == 0 {
+		return
+	}
+
+	message := tgbotapi.NewMessage(b.adminChatId, text)
+	message.DisableWebPagePreview = true
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🙈 Скрыть везде", fmt.Sprintf("adm:hide:%d", offer.Id)),
+			tgbotapi.NewInlineKeyboardButtonData("⛔️ Номер в ЧС", fmt.Sprintf("adm:ban:%d", offer.Id)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Отклонить", fmt.Sprintf("adm:dismiss:%d", offer.Id)),
+		),
+	)
+	_, err = b.Send(message)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendReport.Send] error:", err)
+	}
+}
+
+// moderateCallback - the decision of the admin about the reports of the
+//  offer: `adm:hide:offerId`, `adm:ban:offerId` or `adm:dismiss:offerId`
+func (b *Bot) moderateCallback(ctx context.Context, req *Request) {
+	chatId := req.Chat.ID
+	parts := strings.Split(req.Callback.Data, ":")
+	if len(parts) != 3 {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	offerId, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	var status, result string
+	switch parts[1] {
+	case "hide":
+		status, result = structs.ReportHidden, "Скрыто для всех"
+		err = b.storage.HideOffer(ctx, offerId)
+	case "ban":
+		var phone string
+		status = structs.ReportBlacklisted
+		phone, err = b.storage.BlacklistOfferPhone(ctx, structs.GlobalBlacklist, offerId, "report")
+		result = "Номер " + phone + " в черном списке"
+		if err == nil && phone == "" {
+			result = "У объявления нет номера, скрыто для всех"
+			err = b.storage.HideOffer(ctx, offerId)
+		}
+	case "dismiss":
+		status, result = structs.ReportDismissed, "Жалоба отклонена"
+	default:
+		b.unknownCallback(ctx, req)
+		return
+	}
+	if err != nil {
+		b.SendError("moderateCallback."+parts[1], err, chatId)
+		return
+	}
+
+	err = b.storage.ResolveReports(ctx, offerId, status)
+	if err != nil {
+		b.SendError("moderateCallback.ResolveReports", err, chatId)
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatId, req.Message.MessageID, req.Message.Text+fmt.Sprintf(reportResolvedText, result))
+	edit.DisableWebPagePreview = true
+	_, err = b.Send(edit)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[moderateCallback.Send] error:", err)
+	}
+}
+
+func reportTitle(reason string) string {
+	for _, r := range reportReasons {
+		if r.reason == reason {
+			return r.title
+		}
+	}
+	return ""
+}
@@ -2,34 +2,74 @@ package bot
 
 import (
 	"context"
+	"fmt"
 	"log"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/getsentry/sentry-go"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 
+	"github.com/comov/hsearch/bot/fsm"
+	"github.com/comov/hsearch/bot/input"
 	"github.com/comov/hsearch/bot/settings"
-	"github.com/comov/hsearch/structs"
 )
 
-// answer - when we ask a question, we expect an answer. This structure stores
-//  the wrong answers for deletion and the menu to which you want to go back.
-type answer struct {
-	deadline  time.Time
-	callback  func(context.Context, *tgbotapi.Message, answer)
-	currency  string
-	maxErrors int
-	menuId    int
-	messages  []int
-}
-
 const (
 	waitSeconds = 20
 	maxErrors   = 4
 )
 
+// settingsCommand - /settings shows the settings menu as a new message
+func (b *Bot) settingsCommand(ctx context.Context, req *Request) {
+	b.settingsCallback(ctx, &tgbotapi.CallbackQuery{Message: req.Message})
+}
+
+// filterCommand - /filter without arguments shows the filters in the command
+//  syntax, with arguments changes them
+func (b *Bot) filterCommand(ctx context.Context, req *Request) {
+	chat, err := b.storage.ReadChat(ctx, req.Chat.ID)
+	if err != nil {
+		b.SendError("filterCommand.ReadChat", err, req.Chat.ID)
+		return
+	}
+
+	args := strings.TrimSpace(req.Message.CommandArguments())
+	if args != "" {
+		err = input.ParseFilter(args, &chat.Filters, &chat.Enable)
+		if err != nil {
+			log.Println("[filterCommand.ParseFilter] error:", err)
+			part := args
+			if fe, ok := err.(*input.FilterError); ok {
+				part = fe.Part
+			}
+
+			_, err = b.Send(tgbotapi.NewMessage(req.Chat.ID, fmt.Sprintf(filterWrongText, part, "/filter "+input.FilterSyntax)))
+			if err != nil {
+				sentry.CaptureException(err)
+				log.Println("[filterCommand.Send] error:", err)
+			}
+			return
+		}
+
+		err = b.storage.UpdateSettings(ctx, chat)
+		if err != nil {
+			b.SendError("filterCommand.UpdateSettings", err, req.Chat.ID)
+			return
+		}
+	}
+
+	message := tgbotapi.NewMessage(
+		req.Chat.ID,
+		settings.Summary(chat)+"\n\n"+fmt.Sprintf(filterCopyText, input.FormatFilter(chat.Filters, chat.Enable)),
+	)
+	message.ParseMode = tgbotapi.ModeMarkdown
+	_, err = b.Send(message)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[filterCommand.Send] error:", err)
+	}
+}
+
 //// buttons for configs
 // settingsCallback - show all settings for user
 func (b *Bot) settingsCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
@@ -50,7 +90,14 @@ func (b *Bot) settingsCallback(ctx context.Context, query *tgbotapi.CallbackQuer
 func (b *Bot) backCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
 	for text, key := range settings.BackFlowMap {
 		if strings.Contains(query.Message.Text, text) {
-			b.callbacks[key](ctx, query)
+			if h := b.router.findCallback(key); h != nil {
+				h(ctx, &Request{
+					Callback: query,
+					Message:  query.Message,
+					Chat:     query.Message.Chat,
+					Route:    key,
+				})
+			}
 			return
 		}
 	}
@@ -139,63 +186,66 @@ func (b *Bot) withPhotoCallback(ctx context.Context, query *tgbotapi.CallbackQue
 	}
 }
 
-func (b *Bot) priceCallback(_ context.Context, query *tgbotapi.CallbackQuery) {
-	_, err := b.Send(settings.FilterPriceHandler(query.Message, query.Data))
+// suspiciousCallback - turns on or off the filter of the offers which look
+//  like a fraud
+func (b *Bot) suspiciousCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	chat, err := b.storage.ReadChat(ctx, query.Message.Chat.ID)
 	if err != nil {
-		sentry.CaptureException(err)
-		log.Println("[priceCallback.Send] error:", err)
+		b.SendError("suspiciousCallback.ReadChat", err, query.Message.Chat.ID)
 		return
 	}
 
-	b.addWaitCallback(query.Message.Chat.ID, answer{
-		deadline:  time.Now().Add(time.Second * waitSeconds),
-		callback:  b.priceWaiterCallback,
-		currency:  query.Data,
-		menuId:    query.Message.MessageID,
-		maxErrors: maxErrors,
-	})
+	chat.HideSuspicious = query.Data == "suspiciousOn"
+	err = b.storage.UpdateSettings(ctx, chat)
+	if err != nil {
+		b.SendError("suspiciousCallback.UpdateSettings", err, query.Message.Chat.ID)
+		return
+	}
+
+	_, err = b.Send(settings.MainFiltersHandler(query.Message, chat))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[suspiciousCallback.Send] error:", err)
+	}
 }
 
-// priceWaiterCallback - process a response from the user
-func (b *Bot) priceWaiterCallback(ctx context.Context, message *tgbotapi.Message, a answer) {
-	prices := strings.Split(message.Text, "-")
-	if len(prices) < 2 {
-		b.wrongAnswer(ctx, message, a)
+func (b *Bot) priceCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	_, err := b.Send(settings.FilterPriceHandler(query.Message, query.Data))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[priceCallback.Send] error:", err)
 		return
 	}
 
-	from, err := strconv.Atoi(strings.TrimSpace(prices[0]))
+	err = b.startDialog(ctx, query.Message.Chat.ID, statePrice, query.Message.MessageID, map[string]string{
+		"currency": query.Data,
+	})
 	if err != nil {
-		b.wrongAnswer(ctx, message, a)
-		log.Println("[priceWaiterCallback] error:", err)
-		return
+		sentry.CaptureException(err)
+		b.SendError("priceCallback.startDialog", err, query.Message.Chat.ID)
 	}
+}
 
-	to, err := strconv.Atoi(strings.TrimSpace(prices[1]))
+// priceWaiterCallback - process a response from the user
+func (b *Bot) priceWaiterCallback(ctx context.Context, message *tgbotapi.Message, state *fsm.State) (string, error) {
+	prices, err := input.ParsePrice(message.Text, state.Data["currency"])
 	if err != nil {
-		b.wrongAnswer(ctx, message, a)
-		log.Println("[priceWaiterCallback] error:", err)
-		return
+		log.Println("[priceWaiterCallback.ParsePrice] error:", err)
+		return statePrice, fsm.ErrWrongAnswer
 	}
 
 	chat, err := b.storage.ReadChat(ctx, message.Chat.ID)
 	if err != nil {
-		b.SendError("priceWaiterCallback.ReadChat", err, message.Chat.ID)
-		return
+		return fsm.Done, err
 	}
 
-	switch a.currency {
-	case "USD":
-		chat.USD = structs.Price{from, to}
-	case "KGS":
-		chat.KGS = structs.Price{from, to}
-	}
+	input.ApplyPrices(&chat.Filters, prices)
 
 	err = b.storage.UpdateSettings(ctx, chat)
 	if err != nil {
-		b.SendError("priceWaiterCallback.UpdateSettings", err, message.Chat.ID)
-		return
+		return fsm.Done, err
 	}
 
-	b.clearRetry(ctx, message.Chat, message.MessageID)
+	state.Messages = append(state.Messages, message.MessageID)
+	return fsm.Done, nil
 }
@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// snoozePresets - after how many days the offer comes back, in the order of
+//  the buttons
+var snoozePresets = []struct {
+	days   int
+	button string
+	title  string
+}{
+	{1, "Завтра", "завтра"},
+	{3, "Через 3 дня", "через 3 дня"},
+	{7, "Через неделю", "через неделю"},
+}
+
+// snoozeMenu - replaces the buttons of the card with the snooze presets
+func (b *Bot) snoozeMenu(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	offerId, err := b.queryOfferId(ctx, query)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[snoozeMenu.queryOfferId] error:", err)
+		return
+	}
+
+	row := tgbotapi.NewInlineKeyboardRow()
+	for _, preset := range snoozePresets {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			preset.button,
+			fmt.Sprintf("sz:%d:%d", offerId, preset.days),
+		))
+	}
+
+	_, err = b.Send(tgbotapi.NewEditMessageReplyMarkup(
+		query.Message.Chat.ID,
+		query.Message.MessageID,
+		tgbotapi.NewInlineKeyboardMarkup(row, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("« Назад", fmt.Sprintf("sz:%d:", offerId)),
+		)),
+	))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[snoozeMenu.Send] error:", err)
+	}
+}
+
+// snoozeCallback - `sz:offerId:days` hides the offer for the days, without
+//  the days just returns the buttons of the card
+func (b *Bot) snoozeCallback(ctx context.Context, req *Request) {
+	chatId := req.Chat.ID
+	parts := strings.Split(req.Callback.Data, ":")
+	if len(parts) != 3 {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	offerId, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	if parts[2] == "" {
+		err = b.refreshCard(ctx, req.Message.Chat, req.Message.MessageID, offerId)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[snoozeCallback.refreshCard] error:", err)
+		}
+		return
+	}
+
+	title := ""
+	days, _ := strconv.Atoi(parts[2])
+	for _, preset := range snoozePresets {
+		if preset.days == days {
+			title = preset.title
+		}
+	}
+	if title == "" {
+		b.unknownCallback(ctx, req)
+		return
+	}
+
+	until := time.Now().AddDate(0, 0, days).Unix()
+	messagesIds, err := b.storage.Snooze(ctx, offerId, chatId, until)
+	if err != nil {
+		b.SendError("snoozeCallback.Snooze", err, chatId)
+		return
+	}
+
+	for _, id := range messagesIds {
+		_, err := b.bot.DeleteMessage(tgbotapi.NewDeleteMessage(chatId, id))
+		if err != nil {
+			log.Println("[snoozeCallback.DeleteMessage] error:", err)
+		}
+	}
+
+	_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(req.Callback.ID, fmt.Sprintf(snoozedText, title)))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[snoozeCallback.AnswerCallbackQuery] error:", err)
+	}
+}
+
+// SendSnoozeGone - the snoozed offer is removed from the site, tells the
+//  chat instead of sending the card
+func (b *Bot) SendSnoozeGone(_ context.Context, offer *structs.Offer, chatId int64) error {
+	message := tgbotapi.NewMessage(chatId, fmt.Sprintf(snoozeGoneText, offer.Topic, offer.Url))
+	message.DisableWebPagePreview = true
+	_, err := b.Send(message)
+	return err
+}
@@ -0,0 +1,285 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/bot/fsm"
+	"github.com/comov/hsearch/bot/input"
+	"github.com/comov/hsearch/structs"
+)
+
+const (
+	// viewingWait - how long the bot waits for the date of the viewing
+	viewingWait = time.Minute * 5
+
+	// viewingLayout - how the date of the viewing is shown
+	viewingLayout = "02.01.2006 15:04"
+)
+
+// viewingCallback - "Назначить просмотр" under the card asks for the date
+func (b *Bot) viewingCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	chatId := query.Message.Chat.ID
+	offerId, err := b.queryOfferId(ctx, query)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[viewingCallback.queryOfferId] error:", err)
+		return
+	}
+
+	chat, err := b.storage.ReadChat(ctx, chatId)
+	if err != nil {
+		b.SendError("viewingCallback.ReadChat", err, chatId)
+		return
+	}
+
+	message := tgbotapi.NewMessage(chatId, fmt.Sprintf(viewingAskText, chat.Timezone))
+	message.ReplyToMessageID = query.Message.MessageID
+	_, err = b.Send(message)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[viewingCallback.Send] error:", err)
+		return
+	}
+
+	err = b.startDialog(ctx, chatId, stateViewing, 0, map[string]string{
+		"offer": strconv.FormatUint(offerId, 10),
+	})
+	if err != nil {
+		b.SendError("viewingCallback.startDialog", err, chatId)
+	}
+}
+
+// viewingWaiterCallback - the date of the viewing in the timezone of the chat.
+//  The offer moves to the "viewing" status of the pipeline.
+func (b *Bot) viewingWaiterCallback(ctx context.Context, message *tgbotapi.Message, state *fsm.State) (string, error) {
+	chatId := message.Chat.ID
+	offerId, err := strconv.ParseUint(state.Data["offer"], 10, 64)
+	if err != nil {
+		return fsm.Done, err
+	}
+
+	chat, err := b.storage.ReadChat(ctx, chatId)
+	if err != nil {
+		return fsm.Done, err
+	}
+
+	loc := chatLocation(chat)
+	at, err := input.ParseDateTime(message.Text, time.Now().In(loc))
+	if err == input.ErrPastDate {
+		b.sendText(chatId, viewingPastText)
+		return stateViewing, fsm.ErrWrongAnswer
+	}
+	if err != nil {
+		log.Println("[viewingWaiterCallback.ParseDateTime] error:", err)
+		return stateViewing, fsm.ErrWrongAnswer
+	}
+
+	err = b.storage.SaveViewing(ctx, &structs.Viewing{
+		Chat:    chatId,
+		OfferId: offerId,
+		At:      at.Unix(),
+	})
+	if err != nil {
+		return fsm.Done, err
+	}
+
+	err = b.storage.SetOfferStatus(ctx, chatId, offerId, structs.StatusViewing)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[viewingWaiterCallback.SetOfferStatus] error:", err)
+	}
+
+	b.sendText(chatId, fmt.Sprintf(viewingSavedText, at.Format(viewingLayout), durationText(b.remindBefore)))
+	return fsm.Done, nil
+}
+
+// viewingsCommand - /viewings shows the upcoming viewings of the chat
+func (b *Bot) viewingsCommand(ctx context.Context, req *Request) {
+	b.sendViewings(ctx, req.Chat.ID, 0)
+}
+
+// viewingsCallback - buttons of /viewings: `vw:del:id` cancels the viewing
+//  and `vw:ics` sends the calendar file
+func (b *Bot) viewingsCallback(ctx context.Context, req *Request) {
+	chatId := req.Chat.ID
+	parts := strings.Split(req.Callback.Data, ":")
+	switch {
+	case len(parts) == 2 && parts[1] == "ics":
+		b.sendCalendar(ctx, chatId)
+	case len(parts) == 3 && parts[1] == "del":
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			b.unknownCallback(ctx, req)
+			return
+		}
+
+		err = b.storage.DeleteViewing(ctx, chatId, id)
+		if err != nil {
+			b.SendError("viewingsCallback.DeleteViewing", err, chatId)
+			return
+		}
+
+		_, err = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(req.Callback.ID, viewingCanceledText))
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[viewingsCallback.AnswerCallbackQuery] error:", err)
+		}
+		b.sendViewings(ctx, chatId, req.Message.MessageID)
+	default:
+		b.unknownCallback(ctx, req)
+	}
+}
+
+// sendViewings - sends the list of the upcoming viewings or shows it in the
+//  message
+func (b *Bot) sendViewings(ctx context.Context, chatId int64, messageId int) {
+	viewings, err := b.storage.ReadViewings(ctx, chatId, time.Now().Unix())
+	if err != nil {
+		b.SendError("sendViewings.ReadViewings", err, chatId)
+		return
+	}
+
+	var text strings.Builder
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(viewings)+1)
+	if len(viewings) == 0 {
+		text.WriteString(viewingsEmptyText)
+	} else {
+		text.WriteString(viewingsTitleText)
+	}
+
+	for i, viewing := range viewings {
+		text.WriteString(fmt.Sprintf("%d. %s\n", i+1, viewingTime(viewing)))
+		text.WriteString(viewingText(viewing.Offer))
+		text.WriteString("\n")
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d. Отменить", i+1), fmt.Sprintf("vw:del:%d", viewing.Id)),
+		))
+	}
+
+	if len(viewings) != 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📅 Добавить в календарь (.ics)", "vw:ics"),
+		))
+	}
+
+	err = b.sendList(chatId, messageId, text.String(), rows)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendViewings.Send] error:", err)
+	}
+}
+
+// sendCalendar - sends the upcoming viewings as the .ics file
+func (b *Bot) sendCalendar(ctx context.Context, chatId int64) {
+	viewings, err := b.storage.ReadViewings(ctx, chatId, time.Now().Unix())
+	if err != nil {
+		b.SendError("sendCalendar.ReadViewings", err, chatId)
+		return
+	}
+
+	document := tgbotapi.NewDocumentUpload(chatId, tgbotapi.FileBytes{
+		Name:  "viewings.ics",
+		Bytes: viewingsCalendar(viewings, b.remindBefore, time.Now()),
+	})
+	_, err = b.Send(document)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[sendCalendar.Send] error:", err)
+	}
+}
+
+// SendViewingReminder - reminds the chat about the viewing with the address,
+//  the phone and the link
+func (b *Bot) SendViewingReminder(_ context.Context, viewing *structs.Viewing) error {
+	message := tgbotapi.NewMessage(
+		viewing.Chat,
+		fmt.Sprintf(viewingReminderText, viewingTime(viewing), viewingText(viewing.Offer)),
+	)
+	message.DisableWebPagePreview = true
+	_, err := b.Send(message)
+	return err
+}
+
+// timezoneCommand - `/timezone name` sets the timezone of the chat, without
+//  the name shows it
+func (b *Bot) timezoneCommand(ctx context.Context, req *Request) {
+	chat, err := b.storage.ReadChat(ctx, req.Chat.ID)
+	if err != nil {
+		b.SendError("timezoneCommand.ReadChat", err, req.Chat.ID)
+		return
+	}
+
+	name := strings.TrimSpace(req.Message.CommandArguments())
+	if name != "" {
+		loc, err := input.Location(name)
+		if err != nil {
+			b.sendText(req.Chat.ID, timezoneWrongText)
+			return
+		}
+
+		chat.Timezone = loc.String()
+		err = b.storage.UpdateSettings(ctx, chat)
+		if err != nil {
+			b.SendError("timezoneCommand.UpdateSettings", err, req.Chat.ID)
+			return
+		}
+	}
+
+	b.sendText(req.Chat.ID, fmt.Sprintf(timezoneText, chat.Timezone))
+}
+
+// chatLocation - the timezone of the chat, the default one if it is broken
+func chatLocation(chat *structs.Chat) *time.Location {
+	loc, err := input.Location(chat.Timezone)
+	if err != nil {
+		loc, _ = input.Location(structs.DefaultTimezone)
+	}
+	return loc
+}
+
+// viewingTime - the date of the viewing in the timezone of the chat
+func viewingTime(viewing *structs.Viewing) string {
+	loc := chatLocation(&structs.Chat{Timezone: viewing.Timezone})
+	return time.Unix(viewing.At, 0).In(loc).Format(viewingLayout)
+}
+
+// viewingText - what is needed to get to the viewing
+func viewingText(offer *structs.Offer) string {
+	var text strings.Builder
+	text.WriteString(offer.Topic)
+	text.WriteString("\n")
+	if offer.District != "" {
+		text.WriteString("Район: ")
+		text.WriteString(offer.District)
+		text.WriteString("\n")
+	}
+	if offer.Phone != "" {
+		text.WriteString("Номер: ")
+		text.WriteString(offer.Phone)
+		text.WriteString("\n")
+	}
+	text.WriteString(offer.Url)
+	text.WriteString("\n")
+	return text.String()
+}
+
+// durationText - "2 ч 30 мин"
+func durationText(d time.Duration) string {
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%d мин", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%d ч", hours)
+	}
+	return fmt.Sprintf("%d ч %d мин", hours, minutes)
+}
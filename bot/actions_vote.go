@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// markdownReplacer - removes symbols of markdown from the names of voters,
+//  the card is sent in markdown
+var markdownReplacer = strings.NewReplacer("_", "", "*", "", "`", "", "[", "", "]", "")
+
+// voteCommand - `/vote any|majority|all` sets the rule of hiding the offer in
+//  the group, `/vote` shows the current rule
+func (b *Bot) voteCommand(ctx context.Context, req *Request) {
+	if !req.Chat.IsGroup() && !req.Chat.IsSuperGroup() {
+		b.sendText(req.Chat.ID, voteOnlyGroupText)
+		return
+	}
+
+	chat, err := b.storage.ReadChat(ctx, req.Chat.ID)
+	if err != nil {
+		b.SendError("voteCommand.ReadChat", err, req.Chat.ID)
+		return
+	}
+
+	rule := strings.ToLower(strings.TrimSpace(req.Message.CommandArguments()))
+	switch rule {
+	case "":
+	case structs.VoteAny, structs.VoteMajority, structs.VoteAll:
+		chat.VoteRule = rule
+		err = b.storage.UpdateSettings(ctx, chat)
+		if err != nil {
+			b.SendError("voteCommand.UpdateSettings", err, req.Chat.ID)
+			return
+		}
+	default:
+		b.sendText(req.Chat.ID, voteRuleWrongText)
+		return
+	}
+
+	b.sendText(req.Chat.ID, fmt.Sprintf(voteRuleText, voteRuleName(chat.VoteRule)))
+}
+
+// upVote - 👍 of the group member
+func (b *Bot) upVote(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	b.vote(ctx, query, 1)
+}
+
+// downVote - 👎 of the group member, the offer is hidden when the vote rule
+//  of the chat is met
+func (b *Bot) downVote(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	b.vote(ctx, query, -1)
+}
+
+func (b *Bot) vote(ctx context.Context, query *tgbotapi.CallbackQuery, value int) {
+	chatId := query.Message.Chat.ID
+	offerId, err := b.queryOfferId(ctx, query)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[vote.queryOfferId] error:", err)
+		return
+	}
+
+	err = b.storage.Vote(ctx, chatId, offerId, &structs.Vote{
+		User:     int64(query.From.ID),
+		Username: voterName(query.From),
+		Value:    value,
+	})
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[vote.Vote] error:", err)
+		return
+	}
+
+	votes, err := b.storage.ReadVotes(ctx, chatId, offerId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[vote.ReadVotes] error:", err)
+		return
+	}
+
+	if value < 0 && b.voteRuleMet(ctx, chatId, votes) {
+		err = b.hideOffer(ctx, offerId, chatId)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[vote.hideOffer] error:", err)
+			return
+		}
+		b.answerVote(query, voteHiddenText)
+		return
+	}
+
+	b.answerVote(query, voteSavedText)
+
+	err = b.refreshCard(ctx, query.Message.Chat, query.Message.MessageID, offerId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[vote.refreshCard] error:", err)
+	}
+}
+
+// voteRuleMet - checks the 👎 of the group by the vote rule of the chat. The
+//  bot is a member of the group too, so it is not counted.
+func (b *Bot) voteRuleMet(ctx context.Context, chatId int64, votes []*structs.Vote) bool {
+	_, down := countVotes(votes)
+	chat, err := b.storage.ReadChat(ctx, chatId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[voteRuleMet.ReadChat] error:", err)
+		return false
+	}
+
+	if chat.VoteRule == structs.VoteAny || chat.VoteRule == "" {
+		return down > 0
+	}
+
+	members, err := b.bot.GetChatMembersCount(tgbotapi.ChatConfig{ChatID: chatId})
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[voteRuleMet.GetChatMembersCount] error:", err)
+		return false
+	}
+	members--
+
+	if chat.VoteRule == structs.VoteMajority {
+		return down*2 > members
+	}
+	return down >= members
+}
+
+func (b *Bot) answerVote(query *tgbotapi.CallbackQuery, text string) {
+	_, err := b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, text))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[vote.AnswerCallbackQuery] error:", err)
+	}
+}
+
+// countVotes - how many 👍 and 👎 the offer has
+func countVotes(votes []*structs.Vote) (int, int) {
+	up, down := 0, 0
+	for _, vote := range votes {
+		if vote.Value > 0 {
+			up++
+		} else {
+			down++
+		}
+	}
+	return up, down
+}
+
+// voterName - the name of the member shown in the card
+func voterName(user *tgbotapi.User) string {
+	name := markdownReplacer.Replace(strings.TrimSpace(user.FirstName + " " + user.LastName))
+	if name == "" {
+		name = markdownReplacer.Replace(user.UserName)
+	}
+	return name
+}
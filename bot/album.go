@@ -0,0 +1,317 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/imagestore"
+	"github.com/comov/hsearch/parser"
+	"github.com/comov/hsearch/structs"
+)
+
+const (
+	// maxUrlPhotoSize - Telegram downloads photos by url only up to 5MB
+	maxUrlPhotoSize = 5 << 20
+
+	// maxPhotoSides - Telegram limits: width + height of the photo is not
+	//  more than 10000 and the ratio of the sides is not more than 20
+	maxPhotoSides = 10000
+	maxPhotoRatio = 20
+)
+
+var checkClient = &http.Client{Timeout: time.Second * 5}
+
+// sendAlbum - sends the album of photos and returns the messages and the
+//  number of photos which could not be delivered.
+//
+// The photos which were already sent to Telegram are sent by file_id, so
+//  Telegram does not download them for every chat again. Before sending, the
+//  urls are checked and the dead ones are dropped. If Telegram still could
+//  not load the album, the file_ids are forgotten, we load the photos
+//  ourselves, drop the broken ones and upload the rest.
+func (b *Bot) sendAlbum(ctx context.Context, chatId int64, replyTo int, album []*structs.Image) ([]tgbotapi.Message, int, error) {
+	album, needUpload, failed := b.checkPhotos(album)
+	if len(album) == 0 {
+		return []tgbotapi.Message{}, failed, nil
+	}
+
+	if !needUpload {
+		messages, err := b.sendPhotos(chatId, replyTo, album)
+		if err == nil {
+			b.saveFileIds(ctx, album, messages)
+			return messages, failed, nil
+		}
+		log.Println("[sendAlbum.sendPhotos] error:", err)
+		b.forgetFileIds(ctx, album)
+	}
+
+	album, files, dropped := b.loadPhotos(ctx, album)
+	failed += dropped
+	if len(album) == 0 {
+		return []tgbotapi.Message{}, failed, nil
+	}
+
+	messages, err := b.uploadPhotos(chatId, replyTo, album, files)
+	if err != nil {
+		return messages, failed + len(album), err
+	}
+
+	b.saveFileIds(ctx, album, messages)
+	return messages, failed, nil
+}
+
+// checkPhotos - checks the urls of the photos with HEAD requests. A broken
+//  photo stays in the album only if we have a copy of it, then the album has
+//  to be uploaded.
+func (b *Bot) checkPhotos(album []*structs.Image) ([]*structs.Image, bool, int) {
+	broken := make([]bool, len(album))
+
+	var wg sync.WaitGroup
+	for i, img := range album {
+		if img.FileId != "" || b.images.Url(img.Key, imagestore.SizeLarge) != "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, img *structs.Image) {
+			defer wg.Done()
+			if err := checkPhotoUrl(img.Path); err != nil {
+				log.Println("[checkPhotos] error:", err)
+				broken[i] = true
+			}
+		}(i, img)
+	}
+	wg.Wait()
+
+	checked := make([]*structs.Image, 0, len(album))
+	needUpload, failed := false, 0
+	for i, img := range album {
+		if !broken[i] {
+			checked = append(checked, img)
+			continue
+		}
+
+		if b.images != nil && img.Key != "" {
+			checked = append(checked, img)
+			needUpload = true
+			continue
+		}
+		failed++
+	}
+	return checked, needUpload, failed
+}
+
+// checkPhotoUrl - the photo must exist, be a picture and not be too large
+//  for Telegram. If the site does not support HEAD, the photo is considered
+//  alive.
+func checkPhotoUrl(url string) error {
+	res, err := checkClient.Head(url)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented:
+		return nil
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s: %s", url, res.Status)
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("%s: not an image %s", url, contentType)
+	}
+
+	if res.ContentLength > maxUrlPhotoSize {
+		return fmt.Errorf("%s: too large %d", url, res.ContentLength)
+	}
+	return nil
+}
+
+// loadPhotos - loads the photos which are not on Telegram servers yet and
+//  drops the ones which can not be loaded or which Telegram will not accept
+func (b *Bot) loadPhotos(ctx context.Context, album []*structs.Image) ([]*structs.Image, map[*structs.Image][]byte, int) {
+	loaded := make([]*structs.Image, 0, len(album))
+	files := make(map[*structs.Image][]byte)
+	dropped := 0
+
+	for _, img := range album {
+		if img.FileId != "" {
+			loaded = append(loaded, img)
+			continue
+		}
+
+		data, err := b.loadPhoto(ctx, img)
+		if err != nil {
+			log.Println("[loadPhotos.loadPhoto] error:", err)
+			dropped++
+			continue
+		}
+
+		if err := validPhoto(data); err != nil {
+			log.Println("[loadPhotos.validPhoto]", img.Path, "error:", err)
+			dropped++
+			continue
+		}
+
+		loaded = append(loaded, img)
+		files[img] = data
+	}
+	return loaded, files, dropped
+}
+
+// loadPhoto - returns the photo from the image store or from the site
+func (b *Bot) loadPhoto(ctx context.Context, img *structs.Image) ([]byte, error) {
+	if b.images != nil && img.Key != "" {
+		data, err := b.images.Load(ctx, img.Key, imagestore.SizeLarge)
+		if err == nil {
+			return data, nil
+		}
+		log.Println("[loadPhoto.images.Load] error:", err)
+	}
+	return parser.GetImageByUrl(img.Path)
+}
+
+// validPhoto - checks that the data is a picture with sizes Telegram accepts
+func validPhoto(data []byte) error {
+	cnf, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if cnf.Width == 0 || cnf.Height == 0 || cnf.Width+cnf.Height > maxPhotoSides {
+		return fmt.Errorf("wrong sizes %dx%d", cnf.Width, cnf.Height)
+	}
+
+	if cnf.Width/cnf.Height > maxPhotoRatio || cnf.Height/cnf.Width > maxPhotoRatio {
+		return fmt.Errorf("wrong ratio %dx%d", cnf.Width, cnf.Height)
+	}
+	return nil
+}
+
+// sendPhotos - sends the photos by file_id or url. Telegram does not accept
+//  an album of one photo, so it is sent as a usual photo.
+func (b *Bot) sendPhotos(chatId int64, replyTo int, album []*structs.Image) ([]tgbotapi.Message, error) {
+	if len(album) == 1 {
+		message := tgbotapi.NewPhotoShare(chatId, b.photoMedia(album[0]))
+		message.ReplyToMessageID = replyTo
+		msg, err := b.Send(message)
+		if err != nil {
+			return []tgbotapi.Message{}, err
+		}
+		return []tgbotapi.Message{msg}, nil
+	}
+
+	medias := make([]interface{}, 0, len(album))
+	for _, img := range album {
+		medias = append(medias, tgbotapi.NewInputMediaPhoto(b.photoMedia(img)))
+	}
+
+	message := tgbotapi.NewMediaGroup(chatId, medias)
+	message.ReplyToMessageID = replyTo
+	return b.SendGroupPhotos(message)
+}
+
+// uploadPhotos - uploads the loaded photos, the others are sent by file_id
+func (b *Bot) uploadPhotos(chatId int64, replyTo int, album []*structs.Image, files map[*structs.Image][]byte) ([]tgbotapi.Message, error) {
+	if len(album) == 1 {
+		img := album[0]
+		var message tgbotapi.PhotoConfig
+		if data, ok := files[img]; ok {
+			message = tgbotapi.NewPhotoUpload(chatId, tgbotapi.FileBytes{Name: "photo.jpg", Bytes: data})
+		} else {
+			message = tgbotapi.NewPhotoShare(chatId, img.FileId)
+		}
+		message.ReplyToMessageID = replyTo
+
+		msg, err := b.Send(message)
+		if err != nil {
+			return []tgbotapi.Message{}, err
+		}
+		return []tgbotapi.Message{msg}, nil
+	}
+
+	attachments := make(map[string][]byte)
+	medias := make([]interface{}, 0, len(album))
+	for i, img := range album {
+		data, ok := files[img]
+		if !ok {
+			medias = append(medias, tgbotapi.NewInputMediaPhoto(img.FileId))
+			continue
+		}
+
+		name := fmt.Sprintf("photo%d", i)
+		attachments[name] = data
+		medias = append(medias, tgbotapi.NewInputMediaPhoto("attach://"+name))
+	}
+
+	message := tgbotapi.NewMediaGroup(chatId, medias)
+	message.ReplyToMessageID = replyTo
+	return b.UploadGroupPhotos(message, attachments)
+}
+
+// saveFileIds - Telegram returns the messages of the album in the same order
+//  as the photos were sent, so we remember file_id of every new photo
+func (b *Bot) saveFileIds(ctx context.Context, album []*structs.Image, messages []tgbotapi.Message) {
+	if len(album) != len(messages) {
+		return
+	}
+
+	for i, msg := range messages {
+		img := album[i]
+		if img.FileId != "" || msg.Photo == nil || len(*msg.Photo) == 0 {
+			continue
+		}
+
+		// sizes are sorted from the smallest, the last one is the original
+		photos := *msg.Photo
+		img.FileId = photos[len(photos)-1].FileID
+		err := b.storage.SaveImageFileId(ctx, img.Path, img.FileId)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[saveFileIds.SaveImageFileId] error:", err)
+		}
+	}
+}
+
+// forgetFileIds - one of the file_ids may be stale, Telegram does not say
+//  which one, so all of them are forgotten. The photos are uploaded again and
+//  saveFileIds remembers the new ones.
+func (b *Bot) forgetFileIds(ctx context.Context, album []*structs.Image) {
+	for _, img := range album {
+		if img.FileId == "" {
+			continue
+		}
+
+		img.FileId = ""
+		err := b.storage.SaveImageFileId(ctx, img.Path, "")
+		if err != nil {
+			sentry.CaptureException(err)
+			log.Println("[forgetFileIds.SaveImageFileId] error:", err)
+		}
+	}
+}
+
+// photoMedia - what to send to Telegram as the photo: file_id if the photo
+//  was already sent, our copy if it is available from the outside or the
+//  url on the site
+func (b *Bot) photoMedia(img *structs.Image) string {
+	if img.FileId != "" {
+		return img.FileId
+	}
+	if u := b.images.Url(img.Key, imagestore.SizeLarge); u != "" {
+		return u
+	}
+	return img.Path
+}
@@ -2,46 +2,87 @@ package bot
 
 import (
 	"context"
-	"github.com/comov/hsearch/bot/settings"
 	"log"
-	"sync"
 	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/go-telegram-bot-api/telegram-bot-api"
 
+	"github.com/comov/hsearch/bot/fsm"
 	"github.com/comov/hsearch/configs"
+	"github.com/comov/hsearch/imagestore"
 	"github.com/comov/hsearch/structs"
 )
 
 type (
 	Storage interface {
-		Dislike(ctx context.Context, msgId int, chatId int64) ([]int, error)
+		Dislike(ctx context.Context, offerId uint64, chatId int64) ([]int, error)
+		Undislike(ctx context.Context, offerId uint64, chatId int64) error
+		ReadDisliked(ctx context.Context, chatId int64, limit int) ([]*structs.Offer, error)
+		SetDislikeReason(ctx context.Context, offerId uint64, chatId int64, reason string) error
+		ReadDislikedByReason(ctx context.Context, chatId int64, reason string) ([]*structs.Offer, error)
+		Like(ctx context.Context, offerId uint64, chatId int64) error
+		Unlike(ctx context.Context, offerId uint64, chatId int64) error
+		IsLiked(ctx context.Context, offerId uint64, chatId int64) (bool, error)
+		ReadFavorites(ctx context.Context, chatId int64, offset, limit int) ([]*structs.Offer, int, error)
 		Feedback(ctx context.Context, chat int64, username, body string) error
-
-		SaveMessage(ctx context.Context, msgId int, offerId uint64, chat int64, kind string) error
-		ReadOfferDescription(ctx context.Context, msgId int, chatId int64) (uint64, string, error)
-		ReadOfferImages(ctx context.Context, msgId int, chatId int64) (uint64, []string, error)
+		Vote(ctx context.Context, chatId int64, offerId uint64, vote *structs.Vote) error
+		ReadVotes(ctx context.Context, chatId int64, offerId uint64) ([]*structs.Vote, error)
+		SetOfferStatus(ctx context.Context, chatId int64, offerId uint64, status string) error
+		ReadOfferStatus(ctx context.Context, chatId int64, offerId uint64) (string, error)
+		ReadPipeline(ctx context.Context, chatId int64) ([]*structs.Offer, error)
+		SaveViewing(ctx context.Context, viewing *structs.Viewing) error
+		DeleteViewing(ctx context.Context, chatId, id int64) error
+		ReadViewings(ctx context.Context, chatId, from int64) ([]*structs.Viewing, error)
+		AddNote(ctx context.Context, chatId int64, offerId uint64, note *structs.Note) error
+		ReadNotes(ctx context.Context, chatId int64, offerIds ...uint64) (map[uint64][]*structs.Note, error)
+		Snooze(ctx context.Context, offerId uint64, chatId int64, until int64) ([]int, error)
+		AddReport(ctx context.Context, report *structs.Report) (int, error)
+		ResolveReports(ctx context.Context, offerId uint64, status string) error
+		HideOffer(ctx context.Context, offerId uint64) error
+		BlacklistOfferPhone(ctx context.Context, chatId int64, offerId uint64, reason string) (string, error)
+		BlacklistPhone(ctx context.Context, chatId int64, phone, reason string) error
+		ReadBlacklist(ctx context.Context, chatId int64) ([]*structs.BlacklistEntry, error)
+		ReadMarketStats(ctx context.Context, filters structs.Filters) ([]*structs.MarketStat, error)
+		DeleteBlacklist(ctx context.Context, chatId, id int64) error
+		DeleteBlacklistPhone(ctx context.Context, chatId int64, phone string) error
+
+		SaveMessage(ctx context.Context, msgId int, offerId uint64, chat int64, kind, profile string) error
+		ReadOfferIdByMessage(ctx context.Context, msgId int, chatId int64) (uint64, error)
+		ReadMessageProfile(ctx context.Context, msgId int, chatId int64) (string, error)
+		ReadOffer(ctx context.Context, offerId uint64) (*structs.Offer, error)
+		ReadOfferDescription(ctx context.Context, offerId uint64) (string, error)
+		ReadOfferImages(ctx context.Context, offerId uint64) ([]*structs.Image, error)
+		SaveImageFileId(ctx context.Context, path, fileId string) error
 
 		ReadChat(ctx context.Context, id int64) (*structs.Chat, error)
 		CreateChat(ctx context.Context, id int64, username, title, cType string) error
 		DeleteChat(ctx context.Context, id int64) error
 		UpdateSettings(ctx context.Context, chat *structs.Chat) error
-	}
 
-	callback func(ctx context.Context, query *tgbotapi.CallbackQuery)
+		ReadSearchProfiles(ctx context.Context, chat int64) ([]*structs.SearchProfile, error)
+		SaveSearchProfile(ctx context.Context, profile *structs.SearchProfile) error
+		EnableSearchProfile(ctx context.Context, chat, id int64, enable bool) error
+		DeleteSearchProfile(ctx context.Context, chat, id int64) error
 
-	Bot struct {
-		bot       *tgbotapi.BotAPI
-		storage   Storage
-		callbacks map[string]callback
-
-		adminChatId int64
-		release     string
+		fsm.Storage
+	}
 
-		// {time.Minutes * 3, b.callbackName(message *tgbotapi.Message)}
-		waitAnswers map[int64]answer
-		waitMutex   sync.Mutex
+	Bot struct {
+		bot     *tgbotapi.BotAPI
+		storage Storage
+		images  *imagestore.Store
+		router  *router
+
+		adminChatId     int64
+		release         string
+		remindBefore    time.Duration
+		reportThreshold int
+		marketDays      int
+
+		// dialogs - the questions we wait an answer for, the state is kept
+		//  in the database
+		dialogs *fsm.Machine
 	}
 )
 
@@ -52,16 +93,27 @@ func NewTelegramBot(cnf *configs.Config, st Storage) *Bot {
 		return nil
 	}
 
+	images, err := imagestore.New(cnf)
+	if err != nil {
+		log.Fatalln("[bot.imagestore.New] error: ", err)
+		return nil
+	}
+
 	bb := &Bot{
-		bot:         bot,
-		storage:     st,
-		adminChatId: cnf.TelegramChatId,
-		release:     cnf.Release,
-		callbacks:   make(map[string]callback, 0),
-		waitAnswers: make(map[int64]answer),
+		bot:             bot,
+		storage:         st,
+		images:          images,
+		adminChatId:     cnf.TelegramChatId,
+		release:         cnf.Release,
+		remindBefore:    cnf.ViewingRemindTime,
+		reportThreshold: cnf.ReportThreshold,
+		marketDays:      cnf.MarketDays,
+		router:          newRouter(),
+		dialogs:         fsm.New(st),
 	}
 
-	bb.registerCallbacks()
+	bb.registerRoutes()
+	bb.registerDialogs()
 	return bb
 }
 
@@ -81,143 +133,134 @@ func (b *Bot) Start() {
 
 	log.Printf("[bot] Start listen Telegram chanel. Version %s\n", b.release)
 	for update := range updates {
-		ctx := context.Background()
-		if update.CallbackQuery != nil {
-			go b.callbackHandler(ctx, update)
-		}
-
-		if update.Message != nil {
-			go b.messageHandler(ctx, update)
-		}
-
-		if update.ChannelPost != nil {
-			go b.channelHandler(ctx, update)
-		}
+		go b.router.Dispatch(context.Background(), update)
 	}
 }
 
-// registerCallbacks - register all callbacks
-func (b *Bot) registerCallbacks() {
+// registerRoutes - register all commands and callbacks
+func (b *Bot) registerRoutes() {
+	b.router.Use(b.recoverMiddleware, b.logMiddleware, b.timingMiddleware)
+	b.router.NotFound(b.unknownCommand, b.unknownCallback)
+	b.router.Text(b.answerListener)
+
+	// commands
+	b.router.Command("start", b.textCommand(b.start))
+	b.router.Command("stop", b.textCommand(b.stop))
+	b.router.Command("help", b.textCommand(b.help))
+	b.router.Command("settings", b.settingsCommand)
+	b.router.Command("filter", b.filterCommand)
+	b.router.Command("profiles", b.profilesCommand)
+	b.router.Command("profile", b.profileCommand)
+	b.router.Command("favorites", b.favoritesCommand)
+	b.router.Command("disliked", b.dislikedCommand)
+	b.router.Command("pipeline", b.pipelineCommand)
+	b.router.Command("viewings", b.viewingsCommand)
+	b.router.Command("timezone", b.timezoneCommand)
+	b.router.Command("vote", b.voteCommand)
+	b.router.Command("blacklist", b.blacklistCommand)
+	b.router.Command("market", b.marketCommand)
+	b.router.Command("feedback", b.textCommand(b.feedback))
+
+	// admin commands
+	b.router.Command("ban", b.adminOnly(b.banCommand))
+	b.router.Command("unban", b.adminOnly(b.unbanCommand))
+
 	// order callbacks
-	b.callbacks["dislike"] = b.dislike
-	b.callbacks["description"] = b.description
-	b.callbacks["photo"] = b.photo
+	b.router.Callback("like", onCallback(b.like))
+	b.router.Callback("unlike", onCallback(b.unlike))
+	b.router.Callback("dislike", onCallback(b.dislike))
+	b.router.Callback("up", onCallback(b.upVote))
+	b.router.Callback("down", onCallback(b.downVote))
+	b.router.Callback("description", onCallback(b.description))
+	b.router.Callback("photo", onCallback(b.photo))
+	b.router.Callback("status", onCallback(b.statusMenu))
+	b.router.Callback("viewing", onCallback(b.viewingCallback))
+	b.router.Callback("note", onCallback(b.noteCallback))
+	b.router.Callback("snooze", onCallback(b.snoozeMenu))
+	b.router.Callback("report", onCallback(b.reportMenu))
+	b.router.Callback("block", onCallback(b.blockPhone))
 
 	// settings callbacks
-	b.callbacks["back"] = b.backCallback
-	b.callbacks["settings"] = b.settingsCallback
+	b.router.Callback("back", onCallback(b.backCallback))
+	b.router.Callback("settings", onCallback(b.settingsCallback))
 
 	// settings search callbacks
-	b.callbacks["search"] = b.searchCallback
-	b.callbacks["searchOn"] = b.searchCallback
-	b.callbacks["searchOff"] = b.searchCallback
-	b.callbacks["dieselOn"] = b.searchCallback
-	b.callbacks["dieselOff"] = b.searchCallback
-	b.callbacks["houseOn"] = b.searchCallback
-	b.callbacks["houseOff"] = b.searchCallback
-	b.callbacks["lalafoOn"] = b.searchCallback
-	b.callbacks["lalafoOff"] = b.searchCallback
+	b.router.Callback("search", onCallback(b.searchCallback))
+	b.router.Callback("searchOn", onCallback(b.searchCallback))
+	b.router.Callback("searchOff", onCallback(b.searchCallback))
+	b.router.Callback("dieselOn", onCallback(b.searchCallback))
+	b.router.Callback("dieselOff", onCallback(b.searchCallback))
+	b.router.Callback("houseOn", onCallback(b.searchCallback))
+	b.router.Callback("houseOff", onCallback(b.searchCallback))
+	b.router.Callback("lalafoOn", onCallback(b.searchCallback))
+	b.router.Callback("lalafoOff", onCallback(b.searchCallback))
 
 	// settings filters callbacks
-	b.callbacks["filters"] = b.filtersCallback
-	b.callbacks["withPhotoOn"] = b.withPhotoCallback
-	b.callbacks["withPhotoOff"] = b.withPhotoCallback
-	b.callbacks["KGS"] = b.priceCallback
-	b.callbacks["USD"] = b.priceCallback
-}
-
-// callbackHandler - handle all callback from user in go routines
-func (b *Bot) callbackHandler(ctx context.Context, update tgbotapi.Update) {
-	b.callbacks[update.CallbackQuery.Data](ctx, update.CallbackQuery)
+	b.router.Callback("filters", onCallback(b.filtersCallback))
+	b.router.Callback("withPhotoOn", onCallback(b.withPhotoCallback))
+	b.router.Callback("withPhotoOff", onCallback(b.withPhotoCallback))
+	b.router.Callback("suspiciousOn", onCallback(b.suspiciousCallback))
+	b.router.Callback("suspiciousOff", onCallback(b.suspiciousCallback))
+	b.router.Callback("KGS", onCallback(b.priceCallback))
+	b.router.Callback("USD", onCallback(b.priceCallback))
+
+	// search profiles callbacks
+	b.router.CallbackPrefix("profile:", b.profileCallback)
+
+	// favorites callbacks
+	b.router.CallbackPrefix("fav:", b.favoritesCallback)
+	b.router.CallbackPrefix("undo:", b.undoDislikeCallback)
+	b.router.CallbackPrefix("reason:", b.reasonCallback)
+	b.router.CallbackPrefix("suggest:", b.suggestCallback)
+
+	// pipeline callbacks
+	b.router.CallbackPrefix("st:", b.statusCallback)
+	b.router.CallbackPrefix("vw:", b.viewingsCallback)
+	b.router.CallbackPrefix("sz:", b.snoozeCallback)
+
+	// reports callbacks
+	b.router.CallbackPrefix("rp:", b.reportCallback)
+	b.router.CallbackPrefix("adm:", b.adminOnly(b.moderateCallback))
+
+	// blacklist callbacks
+	b.router.CallbackPrefix("bl:", b.blacklistCallback)
 }
 
-// messageHandler - handle all user message from user in go routines
-func (b *Bot) messageHandler(ctx context.Context, update tgbotapi.Update) {
-	if update.Message.IsCommand() {
-		msg := ""
-		switch update.Message.Command() {
-		case "start":
-			msg = b.start(ctx, update.Message.Chat)
-		case "stop":
-			msg = b.stop(ctx, update.Message.Chat)
-		case "help":
-			msg = b.help(update.Message)
-		case "settings":
-			b.callbacks["settings"](ctx, &tgbotapi.CallbackQuery{Message: update.Message})
-			return
-		case "feedback":
-			msg = b.feedback(ctx, update.Message.Chat)
-		default:
-			msg = "Нет среди доступных команд :("
-		}
-
-		message := tgbotapi.NewMessage(update.Message.Chat.ID, msg)
-		_, err := b.Send(message)
-		if err != nil {
-			sentry.CaptureException(err)
-			log.Println("[messageHandler.bot.Send] error: ", err)
-		}
-		return
+// onCallback - adapter for the button handlers
+func onCallback(cb func(ctx context.Context, query *tgbotapi.CallbackQuery)) Handler {
+	return func(ctx context.Context, req *Request) {
+		cb(ctx, req.Callback)
 	}
-	b.answerListener(ctx, update.Message)
 }
 
-func (b *Bot) channelHandler(ctx context.Context, update tgbotapi.Update) {
-	if !update.ChannelPost.IsCommand() {
-		return
-	}
-
-	msg := ""
-	switch update.ChannelPost.Command() {
-	case "start":
-		msg = b.start(ctx, update.ChannelPost.Chat)
-	case "stop":
-		msg = b.stop(ctx, update.ChannelPost.Chat)
-	case "help":
-		msg = b.help(update.ChannelPost)
-	case "settings":
-		chat, err := b.storage.ReadChat(ctx, update.ChannelPost.Chat.ID)
-		if err != nil {
-			sentry.CaptureException(err)
-			log.Println("[channelHandler.settings.ReadChat] error:", err)
-			return
-		}
-
-		_, err = b.Send(settings.MainSettingsHandler(update.ChannelPost, chat))
+// textCommand - adapter for the commands which answer with a text
+func (b *Bot) textCommand(cmd func(ctx context.Context, message *tgbotapi.Message) string) Handler {
+	return func(ctx context.Context, req *Request) {
+		_, err := b.Send(tgbotapi.NewMessage(req.Chat.ID, cmd(ctx, req.Message)))
 		if err != nil {
 			sentry.CaptureException(err)
-			log.Println("[channelHandler.settings.Send] error:", err)
+			log.Printf("[%s.Send] error: %s\n", req.Route, err)
 		}
-		return
-	case "feedback":
-		msg = b.feedback(ctx, update.ChannelPost.Chat)
-	default:
-		msg = "Нет среди доступных команд"
 	}
+}
 
-	message := tgbotapi.NewMessage(update.ChannelPost.Chat.ID, msg)
-	_, err := b.Send(message)
+// unknownCommand - answer for the commands which are not registered
+func (b *Bot) unknownCommand(_ context.Context, req *Request) {
+	_, err := b.Send(tgbotapi.NewMessage(req.Chat.ID, unknownCommandText))
 	if err != nil {
 		sentry.CaptureException(err)
-		log.Println("[channelHandler.bot.Send] error: ", err)
+		log.Println("[unknownCommand.Send] error:", err)
 	}
 }
 
-// answerListener - if we need wait answer from some chat, we add waite command
-//  to waitAnswers. This function listen all message and check need wait answer
-//  or not. If need, we call callback and remove from wait map
-func (b *Bot) answerListener(ctx context.Context, message *tgbotapi.Message) {
-	b.waitMutex.Lock()
-	defer b.waitMutex.Unlock()
-
-	answer, ok := b.waitAnswers[message.Chat.ID]
-	if ok {
-		if answer.deadline.Unix() > time.Now().Unix() {
-			answer.callback(ctx, message, answer)
-			return
-		}
-
-		b.clearRetry(ctx, message.Chat, -1)
+// unknownCallback - the button is from an old version of the bot or the data
+//  was forged
+func (b *Bot) unknownCallback(_ context.Context, req *Request) {
+	log.Println("[unknownCallback] unknown data:", req.Callback.Data)
+	_, err := b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(req.Callback.ID, unknownCallbackText))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[unknownCallback.AnswerCallbackQuery] error:", err)
 	}
 }
 
@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/structs"
+)
+
+const (
+	// callbackVersion - the first field of the offer buttons data. Buttons
+	//  without the version were sent before the offer id was put into the
+	//  data, for them the offer is found by the message.
+	callbackVersion = "1"
+
+	// maxCallbackData - Telegram limit for the callback data in bytes
+	maxCallbackData = 64
+)
+
+// errNoMessage - the old button came without the message, so the offer can
+//  not be found
+var errNoMessage = errors.New("callback query without the message")
+
+// offerData - decoded data of the offer button
+type offerData struct {
+	action  string
+	offerId uint64
+}
+
+// offerCallbackData - encodes the action and the offer into the button data
+//  as `1:action:offerId`, for example `1:photo:123`
+func offerCallbackData(action string, offer *structs.Offer) string {
+	data := fmt.Sprintf("%s:%s:%d", callbackVersion, action, offer.Id)
+	if len(data) > maxCallbackData {
+		// can not happen with our actions, but Telegram rejects the whole
+		//  message if one button is wrong
+		return action
+	}
+	return data
+}
+
+// parseOfferCallback - decodes the data of the offer button. Returns false
+//  for the old buttons and for the other callbacks.
+func parseOfferCallback(data string) (offerData, bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 || parts[0] != callbackVersion {
+		return offerData{}, false
+	}
+
+	offerId, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return offerData{}, false
+	}
+
+	return offerData{
+		action:  parts[1],
+		offerId: offerId,
+	}, true
+}
+
+// callbackAction - returns the name of the callback to call for the data
+func callbackAction(data string) string {
+	if od, ok := parseOfferCallback(data); ok {
+		return od.action
+	}
+	return data
+}
+
+// queryOfferId - returns the offer id from the button data. For the old
+//  buttons the offer is found by the message in tg_messages.
+func (b *Bot) queryOfferId(ctx context.Context, query *tgbotapi.CallbackQuery) (uint64, error) {
+	if od, ok := parseOfferCallback(query.Data); ok {
+		return od.offerId, nil
+	}
+	if query.Message == nil {
+		return 0, errNoMessage
+	}
+	return b.storage.ReadOfferIdByMessage(ctx, query.Message.MessageID, query.Message.Chat.ID)
+}
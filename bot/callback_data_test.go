@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// offerActions - the actions of the offer buttons, see getKeyboard
+var offerActions = []string{
+	"like", "unlike", "dislike", "up", "down", "description", "photo",
+	"note", "status", "viewing", "snooze", "report", "block",
+}
+
+func TestOfferCallbackData(t *testing.T) {
+	for _, action := range offerActions {
+		for _, id := range []uint64{1, 123456, math.MaxUint64} {
+			data := offerCallbackData(action, &structs.Offer{Id: id, Site: structs.SiteLalafo})
+			if len(data) > maxCallbackData {
+				t.Errorf("%q has %d bytes, Telegram accepts %d", data, len(data), maxCallbackData)
+			}
+
+			od, ok := parseOfferCallback(data)
+			if !ok {
+				t.Fatalf("parseOfferCallback(%q) is not an offer button", data)
+			}
+			if od.action != action || od.offerId != id {
+				t.Errorf("parseOfferCallback(%q) = %s %d, want %s %d", data, od.action, od.offerId, action, id)
+			}
+			if callbackAction(data) != action {
+				t.Errorf("callbackAction(%q) = %q, want %q", data, callbackAction(data), action)
+			}
+		}
+	}
+}
+
+func TestParseOfferCallback(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		ok   bool
+	}{
+		{"offer button", "1:photo:123", true},
+		{"old button", "photo", false},
+		{"other version", "2:photo:123", false},
+		{"not a number", "1:photo:abc", false},
+		{"other callback", "profile:on:5", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := parseOfferCallback(tt.data); ok != tt.ok {
+				t.Errorf("parseOfferCallback(%q) = %v, want %v", tt.data, ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestQueryOfferIdWithoutMessage(t *testing.T) {
+	b := &Bot{}
+
+	offerId, err := b.queryOfferId(context.Background(), &tgbotapi.CallbackQuery{Data: "1:photo:42"})
+	if err != nil || offerId != 42 {
+		t.Errorf("queryOfferId() = %d, %v, want 42", offerId, err)
+	}
+
+	_, err = b.queryOfferId(context.Background(), &tgbotapi.CallbackQuery{Data: "photo"})
+	if err != errNoMessage {
+		t.Errorf("queryOfferId() error = %v, want %v", err, errNoMessage)
+	}
+}
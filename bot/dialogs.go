@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/bot/fsm"
+	"github.com/comov/hsearch/structs"
+)
+
+// dialog states. When the bot asks a question, the chat moves into the state
+//  and the next message is processed by the step handler.
+const (
+	statePrice    = "price"
+	stateFeedback = "feedback"
+	stateViewing  = "viewing"
+	stateNote     = "note"
+)
+
+// menuKey - the data key of the menu message to go back to when the dialog
+//  is over
+const menuKey = "menu"
+
+// registerDialogs - register the steps of all dialogs
+func (b *Bot) registerDialogs() {
+	b.dialogs.Register(statePrice, fsm.Step{
+		Handler:    b.priceWaiterCallback,
+		Timeout:    time.Second * waitSeconds,
+		MaxRetries: maxErrors,
+	})
+	b.dialogs.Register(stateFeedback, fsm.Step{
+		Handler:    b.feedbackWaiterCallback,
+		Timeout:    feedBackWait,
+		MaxRetries: 1,
+	})
+	b.dialogs.Register(stateViewing, fsm.Step{
+		Handler:    b.viewingWaiterCallback,
+		Timeout:    viewingWait,
+		MaxRetries: maxErrors,
+	})
+	b.dialogs.Register(stateNote, fsm.Step{
+		Handler:    b.noteWaiterCallback,
+		Timeout:    noteWait,
+		MaxRetries: 1,
+	})
+}
+
+// startDialog - moves the chat into the state. If the question was asked in
+//  the menu, menuId is the menu message to show again at the end.
+func (b *Bot) startDialog(ctx context.Context, chatId int64, state string, menuId int, data map[string]string) error {
+	if data == nil {
+		data = make(map[string]string)
+	}
+	if menuId != 0 {
+		data[menuKey] = strconv.Itoa(menuId)
+	}
+
+	_, err := b.dialogs.Start(ctx, chatId, state, data)
+	return err
+}
+
+// answerListener - if the chat is in a dialog, the message is the answer to
+//  our question. The step handler processes it, and we clean up the chat when
+//  the dialog is over.
+func (b *Bot) answerListener(ctx context.Context, req *Request) {
+	message := req.Message
+	result, state, err := b.dialogs.Handle(ctx, message)
+	if err != nil {
+		sentry.CaptureException(err)
+		b.SendError("answerListener.Handle", err, message.Chat.ID)
+	}
+
+	switch result {
+	case fsm.Wrong:
+		b.wrongAnswer(ctx, message, state)
+	case fsm.Finished, fsm.Failed, fsm.Expired:
+		b.finishDialog(ctx, message.Chat, state)
+	}
+}
+
+// wrongAnswer - asks to try again and remembers the question to delete it
+//  at the end
+func (b *Bot) wrongAnswer(ctx context.Context, message *tgbotapi.Message, state *fsm.State) {
+	m, err := b.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(wrongAnswerText, state.Retries)))
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[wrongAnswer.Send] error:", err)
+		return
+	}
+
+	err = b.dialogs.Remember(ctx, state, m.MessageID)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Println("[wrongAnswer.Remember] error:", err)
+	}
+}
+
+// finishDialog - deletes the questions and the wrong answers and goes back
+//  to the menu
+func (b *Bot) finishDialog(ctx context.Context, chat *tgbotapi.Chat, state *structs.ChatState) {
+	for _, id := range state.Messages {
+		_, err := b.Send(tgbotapi.NewDeleteMessage(chat.ID, id))
+		if err != nil {
+			log.Println("[finishDialog.Send] error:", err)
+		}
+	}
+
+	menuId, _ := strconv.Atoi(state.Data[menuKey])
+	if menuId != 0 {
+		b.filtersCallback(ctx, &tgbotapi.CallbackQuery{Message: &tgbotapi.Message{
+			Chat:      chat,
+			MessageID: menuId,
+		}})
+	}
+}
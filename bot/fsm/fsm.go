@@ -0,0 +1,206 @@
+// Package fsm - multi-step dialogs with the chat. When the bot asks
+//  a question, the chat moves into a state and the next message is the
+//  answer. States are stored in the database, so dialogs survive restarts.
+package fsm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// Done - the handler returns it as the next state to finish the dialog
+const Done = ""
+
+// ErrWrongAnswer - the handler returns it when the answer can not be
+//  accepted, the chat gets one more attempt if there are any left
+var ErrWrongAnswer = errors.New("wrong answer")
+
+// Result - what happened with the message
+type Result int
+
+const (
+	// NoDialog - the chat is not in a dialog, the message is not an answer
+	NoDialog Result = iota
+	// Next - the answer is accepted and the dialog moved to the next state
+	Next
+	// Finished - the answer is accepted and the dialog is over
+	Finished
+	// Wrong - the answer is wrong, the chat can try again
+	Wrong
+	// Failed - the answer is wrong and there are no attempts left
+	Failed
+	// Expired - the answer came too late, the dialog is over
+	Expired
+)
+
+type (
+	// State - the chat state in a dialog
+	State = structs.ChatState
+
+	// Handler - processes the answer and returns the next state
+	Handler func(ctx context.Context, message *tgbotapi.Message, state *State) (string, error)
+
+	// Step - a state of the dialog: the handler of the answer, how long to
+	//  wait for it and how many wrong answers are allowed
+	Step struct {
+		Handler    Handler
+		Timeout    time.Duration
+		MaxRetries int
+	}
+
+	Storage interface {
+		ReadChatState(ctx context.Context, chat int64) (*State, error)
+		SaveChatState(ctx context.Context, state *State) error
+		DeleteChatState(ctx context.Context, chat int64) error
+	}
+
+	// Machine - keeps the registered steps and moves chats between them
+	Machine struct {
+		storage Storage
+		steps   map[string]Step
+
+		// locks - answers of one chat are processed one by one, different
+		//  chats do not wait for each other
+		locks sync.Map
+
+		// idle - the chats which are known to be out of a dialog, their
+		//  messages do not read the storage
+		idle sync.Map
+	}
+)
+
+// New - creates the machine on top of the storage
+func New(storage Storage) *Machine {
+	return &Machine{
+		storage: storage,
+		steps:   make(map[string]Step),
+	}
+}
+
+// Register - adds the step with the name
+func (m *Machine) Register(name string, step Step) {
+	m.steps[name] = step
+}
+
+// Start - moves the chat into the state. The previous dialog of the chat,
+//  if any, is forgotten.
+func (m *Machine) Start(ctx context.Context, chat int64, name string, data map[string]string) (*State, error) {
+	step, ok := m.steps[name]
+	if !ok {
+		return nil, errors.New("fsm: unknown state " + name)
+	}
+
+	lock := m.lock(chat)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if data == nil {
+		data = make(map[string]string)
+	}
+
+	state := &State{
+		Chat:     chat,
+		Name:     name,
+		Data:     data,
+		Retries:  step.MaxRetries,
+		Deadline: time.Now().Add(step.Timeout).Unix(),
+		Messages: make([]int, 0),
+	}
+	m.idle.Delete(chat)
+	return state, m.storage.SaveChatState(ctx, state)
+}
+
+// Remember - adds the messages to delete at the end of the dialog
+func (m *Machine) Remember(ctx context.Context, state *State, messages ...int) error {
+	state.Messages = append(state.Messages, messages...)
+	return m.storage.SaveChatState(ctx, state)
+}
+
+// Cancel - finishes the dialog of the chat
+func (m *Machine) Cancel(ctx context.Context, chat int64) error {
+	lock := m.lock(chat)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return m.finish(ctx, chat)
+}
+
+// Handle - if the chat is in a dialog, passes the message to the handler of
+//  the current state and moves the chat to the next one
+func (m *Machine) Handle(ctx context.Context, message *tgbotapi.Message) (Result, *State, error) {
+	lock := m.lock(message.Chat.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, ok := m.idle.Load(message.Chat.ID); ok {
+		return NoDialog, nil, nil
+	}
+
+	state, err := m.storage.ReadChatState(ctx, message.Chat.ID)
+	if err != nil {
+		return NoDialog, nil, err
+	}
+	if state == nil {
+		m.idle.Store(message.Chat.ID, true)
+		return NoDialog, nil, nil
+	}
+
+	step, ok := m.steps[state.Name]
+	if !ok {
+		return NoDialog, state, m.finish(ctx, state.Chat)
+	}
+
+	if time.Now().Unix() > state.Deadline {
+		return Expired, state, m.finish(ctx, state.Chat)
+	}
+
+	next, err := step.Handler(ctx, message, state)
+	if err == ErrWrongAnswer {
+		state.Retries--
+		state.Messages = append(state.Messages, message.MessageID)
+		if state.Retries <= 0 {
+			return Failed, state, m.finish(ctx, state.Chat)
+		}
+
+		state.Deadline = time.Now().Add(step.Timeout).Unix()
+		return Wrong, state, m.storage.SaveChatState(ctx, state)
+	}
+
+	if err != nil || next == Done {
+		if delErr := m.finish(ctx, state.Chat); err == nil {
+			err = delErr
+		}
+		return Finished, state, err
+	}
+
+	nextStep, ok := m.steps[next]
+	if !ok {
+		return Finished, state, errors.New("fsm: unknown state " + next)
+	}
+
+	state.Name = next
+	state.Retries = nextStep.MaxRetries
+	state.Deadline = time.Now().Add(nextStep.Timeout).Unix()
+	return Next, state, m.storage.SaveChatState(ctx, state)
+}
+
+// finish - forgets the dialog of the chat, the next messages of the chat are
+//  not answers
+func (m *Machine) finish(ctx context.Context, chat int64) error {
+	err := m.storage.DeleteChatState(ctx, chat)
+	if err == nil {
+		m.idle.Store(chat, true)
+	}
+	return err
+}
+
+func (m *Machine) lock(chat int64) *sync.Mutex {
+	lock, _ := m.locks.LoadOrStore(chat, new(sync.Mutex))
+	return lock.(*sync.Mutex)
+}
@@ -0,0 +1,171 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// memoryStorage - keeps the states in memory and counts the reads
+type memoryStorage struct {
+	states map[int64]*State
+	reads  int
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{states: make(map[int64]*State)}
+}
+
+func (s *memoryStorage) ReadChatState(_ context.Context, chat int64) (*State, error) {
+	s.reads++
+	return s.states[chat], nil
+}
+
+func (s *memoryStorage) SaveChatState(_ context.Context, state *State) error {
+	s.states[state.Chat] = state
+	return nil
+}
+
+func (s *memoryStorage) DeleteChatState(_ context.Context, chat int64) error {
+	delete(s.states, chat)
+	return nil
+}
+
+func answer(chat int64) *tgbotapi.Message {
+	return &tgbotapi.Message{MessageID: 10, Chat: &tgbotapi.Chat{ID: chat}}
+}
+
+func TestHandle(t *testing.T) {
+	failure := errors.New("failure")
+
+	tests := []struct {
+		name    string
+		next    string
+		err     error
+		retries int
+		expired bool
+		result  Result
+		state   string // the state of the chat after the answer, empty if none
+		wantErr bool
+	}{
+		{"done", Done, nil, 2, false, Finished, "", false},
+		{"next state", "second", nil, 2, false, Next, "second", false},
+		{"unknown next state", "unknown", nil, 2, false, Finished, "first", true},
+		{"wrong answer", "", ErrWrongAnswer, 2, false, Wrong, "first", false},
+		{"last wrong answer", "", ErrWrongAnswer, 1, false, Failed, "", false},
+		{"handler error", "second", failure, 2, false, Finished, "", true},
+		{"timeout", "second", nil, 2, true, Expired, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newMemoryStorage()
+			machine := New(storage)
+			machine.Register("first", Step{
+				Handler: func(context.Context, *tgbotapi.Message, *State) (string, error) {
+					return tt.next, tt.err
+				},
+				Timeout:    time.Minute,
+				MaxRetries: tt.retries,
+			})
+			machine.Register("second", Step{Timeout: time.Minute, MaxRetries: 3})
+
+			_, err := machine.Start(context.Background(), 1, "first", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.expired {
+				storage.states[1].Deadline = time.Now().Add(-time.Second).Unix()
+			}
+
+			result, _, err := machine.Handle(context.Background(), answer(1))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Handle() error = %v, want error %v", err, tt.wantErr)
+			}
+			if result != tt.result {
+				t.Errorf("Handle() = %v, want %v", result, tt.result)
+			}
+
+			state := ""
+			if storage.states[1] != nil {
+				state = storage.states[1].Name
+			}
+			if state != tt.state {
+				t.Errorf("the chat is in %q, want %q", state, tt.state)
+			}
+		})
+	}
+}
+
+func TestHandleWrongAnswer(t *testing.T) {
+	storage := newMemoryStorage()
+	machine := New(storage)
+	machine.Register("first", Step{
+		Handler: func(context.Context, *tgbotapi.Message, *State) (string, error) {
+			return "", ErrWrongAnswer
+		},
+		Timeout:    time.Minute,
+		MaxRetries: 2,
+	})
+
+	_, err := machine.Start(context.Background(), 1, "first", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.states[1].Deadline = time.Now().Add(time.Second).Unix()
+
+	result, state, _ := machine.Handle(context.Background(), answer(1))
+	if result != Wrong || state.Retries != 1 {
+		t.Fatalf("Handle() = %v with %d retries, want Wrong with 1", result, state.Retries)
+	}
+	if state.Deadline <= time.Now().Add(time.Second).Unix() {
+		t.Errorf("the wrong answer does not prolong the deadline")
+	}
+	if len(state.Messages) != 1 {
+		t.Errorf("the wrong answer is not remembered: %v", state.Messages)
+	}
+
+	result, _, _ = machine.Handle(context.Background(), answer(1))
+	if result != Failed {
+		t.Errorf("Handle() = %v, want Failed", result)
+	}
+}
+
+func TestHandleIdleChat(t *testing.T) {
+	storage := newMemoryStorage()
+	machine := New(storage)
+	machine.Register("first", Step{
+		Handler: func(context.Context, *tgbotapi.Message, *State) (string, error) {
+			return Done, nil
+		},
+		Timeout: time.Minute,
+	})
+
+	for i := 0; i < 3; i++ {
+		result, _, err := machine.Handle(context.Background(), answer(1))
+		if err != nil || result != NoDialog {
+			t.Fatalf("Handle() = %v, %v, want NoDialog", result, err)
+		}
+	}
+	if storage.reads != 1 {
+		t.Errorf("the idle chat reads the storage %d times, want 1", storage.reads)
+	}
+
+	_, err := machine.Start(context.Background(), 1, "first", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, _, _ := machine.Handle(context.Background(), answer(1))
+	if result != Finished {
+		t.Errorf("Handle() = %v after Start, want Finished", result)
+	}
+
+	reads := storage.reads
+	result, _, _ = machine.Handle(context.Background(), answer(1))
+	if result != NoDialog || storage.reads != reads {
+		t.Errorf("Handle() = %v after the dialog with %d more reads, want NoDialog without reads", result, storage.reads-reads)
+	}
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/comov/hsearch/structs"
+)
+
+const (
+	// icsLayout - the date in UTC as RFC 5545 wants it
+	icsLayout = "20060102T150405Z"
+
+	// icsLineLength - the lines of the calendar are folded after 75 bytes
+	icsLineLength = 75
+)
+
+var icsReplacer = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// viewingsCalendar - the viewings in the iCalendar format, every event has
+//  the alarm at the time of our reminder
+func viewingsCalendar(viewings []*structs.Viewing, remindBefore time.Duration, now time.Time) []byte {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//hsearch//viewings//RU",
+		"CALSCALE:GREGORIAN",
+	}
+
+	for _, viewing := range viewings {
+		offer := viewing.Offer
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:viewing-%d@hsearch", viewing.Id),
+			"DTSTAMP:"+now.UTC().Format(icsLayout),
+			"DTSTART:"+time.Unix(viewing.At, 0).UTC().Format(icsLayout),
+			"DURATION:PT1H",
+			"SUMMARY:"+icsReplacer.Replace("Просмотр: "+offer.Topic),
+			"LOCATION:"+icsReplacer.Replace(offer.District),
+			"DESCRIPTION:"+icsReplacer.Replace(strings.TrimSpace(viewingText(offer))),
+			"URL:"+offer.Url,
+			"BEGIN:VALARM",
+			"ACTION:DISPLAY",
+			fmt.Sprintf("TRIGGER:-PT%dM", int(remindBefore.Minutes())),
+			"DESCRIPTION:"+icsReplacer.Replace("Просмотр: "+offer.Topic),
+			"END:VALARM",
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var calendar strings.Builder
+	for _, line := range lines {
+		calendar.WriteString(icsFold(line))
+		calendar.WriteString("\r\n")
+	}
+	return []byte(calendar.String())
+}
+
+// icsFold - splits the long line by the whole runes, the next part starts
+//  with a space
+func icsFold(line string) string {
+	var folded strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > icsLineLength {
+			folded.WriteString("\r\n ")
+			length = 1
+		}
+		folded.WriteRune(r)
+		length += size
+	}
+	return folded.String()
+}
@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/comov/hsearch/structs"
+)
+
+func TestIcsFold(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		lines int
+	}{
+		{"short", "SUMMARY:Просмотр", 1},
+		{"exactly 75 bytes", strings.Repeat("a", 75), 1},
+		{"76 bytes", strings.Repeat("a", 76), 2},
+		{"ascii", strings.Repeat("a", 200), 3},
+		{"cyrillic", "DESCRIPTION:" + strings.Repeat("квартира ", 20), 5},
+		{"emoji", strings.Repeat("🏠", 40), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := icsFold(tt.line)
+			lines := strings.Split(folded, "\r\n")
+			if len(lines) != tt.lines {
+				t.Errorf("icsFold() has %d lines, want %d", len(lines), tt.lines)
+			}
+
+			for i, line := range lines {
+				if len(line) > icsLineLength {
+					t.Errorf("line %d has %d bytes", i, len(line))
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a rune: %q", i, line)
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("line %d does not start with a space: %q", i, line)
+				}
+			}
+
+			if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("unfolded line = %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestViewingsCalendar(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	viewings := []*structs.Viewing{{
+		Id: 7,
+		At: time.Date(2026, 10, 20, 12, 30, 0, 0, time.UTC).Unix(),
+		Offer: &structs.Offer{
+			Topic:    "Сдаю 2-комн., центр; 400$",
+			District: "Центр",
+			Url:      "https://example.com/1",
+		},
+	}}
+
+	calendar := string(viewingsCalendar(viewings, 30*time.Minute, now))
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:viewing-7@hsearch\r\n",
+		"DTSTAMP:20261019T120000Z\r\n",
+		"DTSTART:20261020T123000Z\r\n",
+		`SUMMARY:Просмотр: Сдаю 2-комн.\, центр\; 400$` + "\r\n",
+		"TRIGGER:-PT30M\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(calendar, want) {
+			t.Errorf("calendar does not contain %q:\n%s", want, calendar)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package input
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/comov/hsearch/structs"
+)
+
+var (
+	// ErrWrongDate - the text is not a date and a time
+	ErrWrongDate = errors.New("input: wrong date")
+	// ErrPastDate - the date is already in the past
+	ErrPastDate = errors.New("input: date in the past")
+	// ErrWrongTimezone - unknown timezone name or offset
+	ErrWrongTimezone = errors.New("input: wrong timezone")
+
+	// dateTime - "25.10 18:30", "25.10.2026 18:30" or "завтра 18:30"
+	dateTime = regexp.MustCompile(`^(?:(\d{1,2})[./](\d{1,2})(?:[./](\d{2}|\d{4}))?|(сегодня|завтра|послезавтра))[\s,]+(?:в\s+)?(\d{1,2})[:.](\d{2})$`)
+	// utcOffset - "+6", "UTC+6" or "GMT-3"
+	utcOffset = regexp.MustCompile(`^(?:utc|gmt)?\s*([+-]\d{1,2})$`)
+)
+
+// ParseDateTime - parses the date and time of a meeting written by people.
+//  The date without a year is the nearest one in the future.
+func ParseDateTime(text string, now time.Time) (time.Time, error) {
+	m := dateTime.FindStringSubmatch(strings.ToLower(strings.TrimSpace(text)))
+	if m == nil {
+		return time.Time{}, ErrWrongDate
+	}
+
+	hour, _ := strconv.Atoi(m[5])
+	minute, _ := strconv.Atoi(m[6])
+	if hour > 23 || minute > 59 {
+		return time.Time{}, ErrWrongDate
+	}
+
+	var date time.Time
+	if m[4] != "" {
+		days := map[string]int{"сегодня": 0, "завтра": 1, "послезавтра": 2}[m[4]]
+		date = time.Date(now.Year(), now.Month(), now.Day()+days, hour, minute, 0, 0, now.Location())
+	} else {
+		day, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		year := now.Year()
+		if m[3] != "" {
+			year, _ = strconv.Atoi(m[3])
+			if year < 100 {
+				year += 2000
+			}
+		}
+
+		// the date without the year is looked for in the next years, 29.02
+		//  can be 4 years ahead
+		for i := 0; ; i++ {
+			date = time.Date(year+i, time.Month(month), day, hour, minute, 0, 0, now.Location())
+			// time.Date normalizes 31.02 to 03.03
+			valid := date.Day() == day && int(date.Month()) == month
+			if m[3] != "" || (valid && !date.Before(now)) || i == 4 {
+				if !valid {
+					return time.Time{}, ErrWrongDate
+				}
+				break
+			}
+		}
+	}
+
+	if date.Before(now) {
+		return time.Time{}, ErrPastDate
+	}
+	return date, nil
+}
+
+// Location - the timezone by the name like "Asia/Bishkek" or the offset
+//  like "UTC+6". Servers without tzdata know only the default timezone.
+func Location(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if m := utcOffset.FindStringSubmatch(strings.ToLower(name)); m != nil {
+		hours, _ := strconv.Atoi(m[1])
+		if hours < -12 || hours > 14 {
+			return nil, ErrWrongTimezone
+		}
+		return time.FixedZone("UTC"+m[1], hours*60*60), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err == nil && name != "" && name != "Local" {
+		return loc, nil
+	}
+	if name == structs.DefaultTimezone {
+		return time.FixedZone(name, 6*60*60), nil
+	}
+	return nil, ErrWrongTimezone
+}
@@ -0,0 +1,85 @@
+package input
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateTime(t *testing.T) {
+	bishkek := time.FixedZone("Asia/Bishkek", 6*60*60)
+	now := time.Date(2026, 12, 30, 20, 0, 0, 0, bishkek)
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, bishkek)
+	}
+
+	tests := []struct {
+		text string
+		want time.Time
+		err  error
+	}{
+		{"31.12 10:00", date(2026, 12, 31, 10, 0), nil},
+		{"05.01 18:30", date(2027, 1, 5, 18, 30), nil},
+		{"30.12 19:00", date(2027, 12, 30, 19, 0), nil},
+		{"25/10, в 9.05", date(2027, 10, 25, 9, 5), nil},
+		{"01.03.27 12:00", date(2027, 3, 1, 12, 0), nil},
+		{"01.03.2027 12:00", date(2027, 3, 1, 12, 0), nil},
+		{"29.02 12:00", date(2028, 2, 29, 12, 0), nil},
+		{"Сегодня в 21:15", date(2026, 12, 30, 21, 15), nil},
+		{"завтра 18:30", date(2026, 12, 31, 18, 30), nil},
+		{"послезавтра 9:00", date(2027, 1, 1, 9, 0), nil},
+		{"сегодня 19:00", time.Time{}, ErrPastDate},
+		{"25.10.2026 18:30", time.Time{}, ErrPastDate},
+		{"31.02 10:00", time.Time{}, ErrWrongDate},
+		{"29.02.2027 10:00", time.Time{}, ErrWrongDate},
+		{"13.13 10:00", time.Time{}, ErrWrongDate},
+		{"завтра 24:00", time.Time{}, ErrWrongDate},
+		{"завтра 18:60", time.Time{}, ErrWrongDate},
+		{"завтра вечером", time.Time{}, ErrWrongDate},
+		{"", time.Time{}, ErrWrongDate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseDateTime(tt.text, now)
+			if err != tt.err {
+				t.Fatalf("ParseDateTime(%q) error = %v, want %v", tt.text, err, tt.err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDateTime(%q) = %s, want %s", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		err    error
+	}{
+		{"UTC+6", 6 * 60 * 60, nil},
+		{"gmt-3", -3 * 60 * 60, nil},
+		{"+5", 5 * 60 * 60, nil},
+		{"Asia/Bishkek", 6 * 60 * 60, nil},
+		{"UTC+15", 0, ErrWrongTimezone},
+		{"Mars/Olympus", 0, ErrWrongTimezone},
+		{"Local", 0, ErrWrongTimezone},
+		{"", 0, ErrWrongTimezone},
+	}
+
+	for _, tt := range tests {
+		loc, err := Location(tt.name)
+		if err != tt.err {
+			t.Errorf("Location(%q) error = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		_, offset := time.Date(2026, 10, 1, 12, 0, 0, 0, loc).Zone()
+		if offset != tt.offset {
+			t.Errorf("Location(%q) offset = %d, want %d", tt.name, offset, tt.offset)
+		}
+	}
+}
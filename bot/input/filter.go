@@ -0,0 +1,239 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// FilterSyntax - the example of the filters in one line
+const FilterSyntax = "price=300-500usd,20к-40к сом rooms=2-3 photo=yes sites=house,lalafo search=yes"
+
+var (
+	errNoCurrency  = errors.New("currency is not specified")
+	errUnknownKey  = errors.New("unknown filter")
+	errWrongValue  = errors.New("wrong value")
+	errUnknownSite = errors.New("unknown site")
+)
+
+// FilterError - the part of the /filter command which can not be parsed
+type FilterError struct {
+	Part string
+	Err  error
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("input: wrong filter %q: %s", e.Part, e.Err)
+}
+
+// ParseFilter - parses the `key=value` pairs of the /filter command and
+//  applies them to the filters, search=yes|no turns on or off the search and
+//  safe=yes hides the suspicious offers.
+//  Only the named filters are changed. Value may contain spaces: "price=до
+//  400 usd" is one filter.
+func ParseFilter(text string, filters *structs.Filters, enable *bool) error {
+	pairs := make([][2]string, 0)
+	for _, field := range strings.Fields(text) {
+		eq := strings.Index(field, "=")
+		if eq < 0 {
+			if len(pairs) == 0 {
+				return &FilterError{Part: field, Err: errWrongValue}
+			}
+			pairs[len(pairs)-1][1] += " " + field
+			continue
+		}
+		pairs = append(pairs, [2]string{strings.ToLower(field[:eq]), field[eq+1:]})
+	}
+
+	// the filters are changed only if the whole command is correct
+	result, resultEnable := *filters, *enable
+	for _, pair := range pairs {
+		if err := applyFilter(&result, &resultEnable, pair[0], pair[1]); err != nil {
+			return &FilterError{Part: pair[0] + "=" + pair[1], Err: err}
+		}
+	}
+
+	*filters, *enable = result, resultEnable
+	return nil
+}
+
+// FormatFilter - the filters in the /filter syntax, to copy them to another
+//  chat or profile
+func FormatFilter(filters structs.Filters, enable bool) string {
+	sites := make([]string, 0, 3)
+	if filters.Diesel {
+		sites = append(sites, structs.SiteDiesel)
+	}
+	if filters.House {
+		sites = append(sites, structs.SiteHouse)
+	}
+	if filters.Lalafo {
+		sites = append(sites, structs.SiteLalafo)
+	}
+	if len(sites) == 0 {
+		// without sites the search goes on all of them
+		sites = append(sites, "all")
+	}
+
+	return fmt.Sprintf(
+		"price=%susd,%skgs rooms=%s photo=%s safe=%s sites=%s search=%s",
+		priceSyntax(filters.USD),
+		priceSyntax(filters.KGS),
+		roomsSyntax(filters.Rooms),
+		yesNo(filters.Photo),
+		yesNo(filters.HideSuspicious),
+		strings.Join(sites, ","),
+		yesNo(enable),
+	)
+}
+
+// FormatRooms - the rooms filter as people write it
+func FormatRooms(p structs.Price) string {
+	switch {
+	case p[0] == 0 && p[1] == 0:
+		return "любое"
+	case p[1] == 0:
+		return fmt.Sprintf("от %d", p[0])
+	case p[0] == p[1]:
+		return strconv.Itoa(p[0])
+	}
+	return fmt.Sprintf("%d - %d", p[0], p[1])
+}
+
+func applyFilter(filters *structs.Filters, enable *bool, key, value string) error {
+	value = strings.TrimSpace(value)
+	switch key {
+	case "price":
+		prices, err := ParsePrice(value, "")
+		if err != nil {
+			return err
+		}
+		for _, p := range prices {
+			if p.Currency == "" {
+				return errNoCurrency
+			}
+		}
+		ApplyPrices(filters, prices)
+	case "rooms":
+		rooms, err := parseRooms(value)
+		if err != nil {
+			return err
+		}
+		filters.Rooms = rooms
+	case "photo":
+		v, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		filters.Photo = v
+	case "safe":
+		v, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		filters.HideSuspicious = v
+	case "search":
+		v, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		*enable = v
+	case "sites":
+		return parseSites(filters, value)
+	default:
+		return errUnknownKey
+	}
+	return nil
+}
+
+// parseRooms - "2", "2-3", "3+" or "0" for any number of rooms
+func parseRooms(value string) (structs.Price, error) {
+	value = strings.ReplaceAll(value, " ", "")
+	if strings.HasSuffix(value, "+") {
+		from, err := strconv.Atoi(strings.TrimSuffix(value, "+"))
+		if err != nil || from < 0 {
+			return structs.Price{}, errWrongValue
+		}
+		return structs.Price{from, 0}, nil
+	}
+
+	bounds := strings.SplitN(value, "-", 2)
+	from, err := strconv.Atoi(bounds[0])
+	if err != nil || from < 0 {
+		return structs.Price{}, errWrongValue
+	}
+	if len(bounds) == 1 {
+		return structs.Price{from, from}, nil
+	}
+
+	to, err := strconv.Atoi(bounds[1])
+	if err != nil || to < from {
+		return structs.Price{}, errWrongValue
+	}
+	return structs.Price{from, to}, nil
+}
+
+func parseSites(filters *structs.Filters, value string) error {
+	filters.Diesel, filters.House, filters.Lalafo = false, false, false
+	for _, site := range strings.Split(strings.ToLower(value), ",") {
+		switch strings.TrimSpace(site) {
+		case structs.SiteDiesel:
+			filters.Diesel = true
+		case structs.SiteHouse:
+			filters.House = true
+		case structs.SiteLalafo:
+			filters.Lalafo = true
+		case "all":
+			filters.Diesel, filters.House, filters.Lalafo = true, true, true
+		default:
+			return errUnknownSite
+		}
+	}
+	return nil
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "on", "true", "1", "да":
+		return true, nil
+	case "no", "off", "false", "0", "нет":
+		return false, nil
+	}
+	return false, errWrongValue
+}
+
+// priceSyntax - the price in the /filter syntax
+func priceSyntax(p structs.Price) string {
+	switch {
+	case p[0] < 0:
+		return "-1"
+	case p[0] == 0 && p[1] == 0:
+		return "0"
+	case p[1] == 0:
+		return fmt.Sprintf("%d+", p[0])
+	}
+	return fmt.Sprintf("%d-%d", p[0], p[1])
+}
+
+// roomsSyntax - the rooms in the /filter syntax
+func roomsSyntax(p structs.Price) string {
+	switch {
+	case p[0] == 0 && p[1] == 0:
+		return "0"
+	case p[1] == 0:
+		return fmt.Sprintf("%d+", p[0])
+	case p[0] == p[1]:
+		return strconv.Itoa(p[0])
+	}
+	return fmt.Sprintf("%d-%d", p[0], p[1])
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
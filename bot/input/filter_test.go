@@ -0,0 +1,101 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/comov/hsearch/structs"
+)
+
+func TestFilterRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters structs.Filters
+		enable  bool
+	}{
+		{
+			name: "all filters",
+			filters: structs.Filters{
+				USD:            structs.Price{300, 500},
+				KGS:            structs.Price{20000, 0},
+				Rooms:          structs.Price{2, 3},
+				Photo:          true,
+				HideSuspicious: true,
+				House:          true,
+				Lalafo:         true,
+			},
+			enable: true,
+		},
+		{
+			name: "any price and rooms",
+			filters: structs.Filters{
+				Diesel: true,
+				House:  true,
+				Lalafo: true,
+			},
+		},
+		{
+			name: "no search in KGS",
+			filters: structs.Filters{
+				USD:   structs.Price{0, 800},
+				KGS:   structs.Price{-1, -1},
+				Rooms: structs.Price{3, 0},
+				House: true,
+			},
+			enable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := FormatFilter(tt.filters, tt.enable)
+
+			// the filters are parsed over other values to check that every
+			//  filter is written
+			got, gotEnable := structs.Filters{
+				USD:    structs.Price{1, 2},
+				KGS:    structs.Price{1, 2},
+				Rooms:  structs.Price{9, 9},
+				Photo:  !tt.filters.Photo,
+				Diesel: !tt.filters.Diesel,
+			}, !tt.enable
+			if err := ParseFilter(text, &got, &gotEnable); err != nil {
+				t.Fatalf("ParseFilter(%q) error: %s", text, err)
+			}
+
+			if got != tt.filters || gotEnable != tt.enable {
+				t.Errorf("ParseFilter(%q) = %+v %v, want %+v %v", text, got, gotEnable, tt.filters, tt.enable)
+			}
+		})
+	}
+}
+
+func TestParseFilterError(t *testing.T) {
+	tests := []struct {
+		text string
+		part string
+	}{
+		{"rooms=2 color=red", "color=red"},
+		{"price=300-500", "price=300-500"},
+		{"sites=house,olx", "sites=house,olx"},
+		{"photo=maybe", "photo=maybe"},
+		{"дешевле", "дешевле"},
+	}
+
+	for _, tt := range tests {
+		filters, enable := structs.Filters{Rooms: structs.Price{1, 1}}, true
+		err := ParseFilter(tt.text, &filters, &enable)
+		fe, ok := err.(*FilterError)
+		if !ok {
+			t.Errorf("ParseFilter(%q) error = %v, want FilterError", tt.text, err)
+			continue
+		}
+		if fe.Part != tt.part {
+			t.Errorf("ParseFilter(%q) part = %q, want %q", tt.text, fe.Part, tt.part)
+		}
+
+		// the filters are not changed if the command has an error
+		if filters.Rooms != (structs.Price{1, 1}) {
+			t.Errorf("ParseFilter(%q) changed the filters: %+v", tt.text, filters)
+		}
+	}
+}
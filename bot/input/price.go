@@ -0,0 +1,305 @@
+// Package input - parsers of what users type to the bot instead of pressing
+//  the buttons: prices, filters and so on.
+package input
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/comov/hsearch/structs"
+)
+
+const (
+	USD = "USD"
+	KGS = "KGS"
+)
+
+var (
+	// ErrNoPrice - there is no number in the expression
+	ErrNoPrice = errors.New("input: price not found")
+	// ErrWrongRange - the lower bound is greater than the upper one
+	ErrWrongRange = errors.New("input: wrong price range")
+
+	// expressionSeparator - "до 400$, до 30к сом" or "до 400$ или до 30к сом"
+	expressionSeparator = regexp.MustCompile(`\s*(?:[,;]|\s(?:или|or)\s)\s*`)
+	// decimalComma - "1,5к" is a number, not two expressions
+	decimalComma = regexp.MustCompile(`(\d),(\d)`)
+	// thousandsSpace - "25 000" is one number
+	thousandsSpace = regexp.MustCompile(`(\d)\s(\d{3})(\D|$)`)
+)
+
+// PriceRange - the price filter for the currency. Price{0, 0} is any price,
+//  Price{from, 0} has no upper bound, Price{-1, -1} means do not search in
+//  this currency.
+type PriceRange struct {
+	Currency string
+	Price    structs.Price
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenWord
+	tokenDash
+	tokenPlus
+)
+
+type token struct {
+	kind  tokenKind
+	value float64
+	word  string
+
+	// multiplier - "к" or "тыс" after the number
+	multiplier float64
+}
+
+// ParsePrice - parses what the user typed as the price: "10000 - 20000",
+//  "до 400$", "300-500 usd", "30к сом", "от 25000", "25000+", "с 20 до 30
+//  тыс".
+//  Several expressions separated by a comma or "или" set the prices for
+//  different currencies. If the currency is not named, defaultCurrency is
+//  used.
+func ParsePrice(text, defaultCurrency string) ([]PriceRange, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = strings.NewReplacer("—", "-", "–", "-", "\u00a0", " ").Replace(text)
+	text = decimalComma.ReplaceAllString(text, "$1.$2")
+	for thousandsSpace.MatchString(text) {
+		text = thousandsSpace.ReplaceAllString(text, "$1$2$3")
+	}
+
+	ranges := make([]PriceRange, 0)
+	for _, expression := range expressionSeparator.Split(text, -1) {
+		if expression == "" {
+			continue
+		}
+
+		r, err := parseExpression(expression, defaultCurrency)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+
+	if len(ranges) == 0 {
+		return nil, ErrNoPrice
+	}
+	return ranges, nil
+}
+
+// ApplyPrices - sets the parsed prices to the filters
+func ApplyPrices(filters *structs.Filters, ranges []PriceRange) {
+	for _, r := range ranges {
+		switch r.Currency {
+		case USD:
+			filters.USD = r.Price
+		case KGS:
+			filters.KGS = r.Price
+		}
+	}
+}
+
+// FormatPrice - the price filter as people write it
+func FormatPrice(p structs.Price) string {
+	switch {
+	case p[0] < 0:
+		return "не искать"
+	case p[0] == 0 && p[1] == 0:
+		return "любая"
+	case p[1] == 0:
+		return fmt.Sprintf("от %d", p[0])
+	case p[0] == 0:
+		return fmt.Sprintf("до %d", p[1])
+	}
+	return fmt.Sprintf("%d - %d", p[0], p[1])
+}
+
+func parseExpression(expression, currency string) (PriceRange, error) {
+	switch strings.TrimSpace(expression) {
+	case "-1", "нет", "не искать":
+		return PriceRange{Currency: currency, Price: structs.Price{-1, -1}}, nil
+	case "0", "любая", "любую", "все", "всё":
+		return PriceRange{Currency: currency, Price: structs.Price{0, 0}}, nil
+	}
+
+	tokens := foldMultipliers(tokenize(expression))
+	if len(tokens) >= 2 && tokens[0].kind == tokenDash && tokens[1].kind == tokenNumber && tokens[1].value == 1 {
+		// "-1usd" turns off the currency
+		for _, t := range tokens[2:] {
+			if c := currencyOf(t.word); c != "" {
+				currency = c
+			}
+		}
+		return PriceRange{Currency: currency, Price: structs.Price{-1, -1}}, nil
+	}
+
+	var (
+		from, to       float64
+		hasFrom, hasTo bool
+		bound          = ""
+	)
+
+	for i, t := range tokens {
+		switch t.kind {
+		case tokenNumber:
+			nextIsDash := dashFollows(tokens, i)
+			switch {
+			case bound == "from", bound == "" && !hasFrom && !hasTo && nextIsDash:
+				from, hasFrom = t.value, true
+			default:
+				// "до 400", "300 - 500" and a bare number which is the
+				//  budget: "400$" is "до 400$"
+				to, hasTo = t.value, true
+			}
+			bound = ""
+		case tokenDash:
+			bound = "to"
+		case tokenPlus:
+			// "25000+" is "от 25000"
+			if i > 0 && tokens[i-1].kind == tokenNumber && hasTo && !hasFrom {
+				from, hasFrom = to, true
+				to, hasTo = 0, false
+			}
+		case tokenWord:
+			nextIsNumber := i+1 < len(tokens) && tokens[i+1].kind == tokenNumber
+			switch {
+			case t.word == "от", t.word == "с" && nextIsNumber:
+				bound = "from"
+			case t.word == "до", t.word == "по":
+				bound = "to"
+			default:
+				if c := currencyOf(t.word); c != "" {
+					currency = c
+				}
+			}
+		}
+	}
+
+	if !hasFrom && !hasTo {
+		return PriceRange{}, ErrNoPrice
+	}
+
+	p := structs.Price{int(from), int(to)}
+	if p[0] < 0 || p[1] < 0 || (p[1] != 0 && p[0] > p[1]) {
+		return PriceRange{}, ErrWrongRange
+	}
+	return PriceRange{Currency: currency, Price: p}, nil
+}
+
+// dashFollows - the number is the lower bound of the range: the dash goes
+//  after it, maybe after the currency: "300$-500$", "300 usd - 500 usd"
+func dashFollows(tokens []token, i int) bool {
+	for _, t := range tokens[i+1:] {
+		if t.kind == tokenWord && currencyOf(t.word) != "" {
+			continue
+		}
+		return t.kind == tokenDash
+	}
+	return false
+}
+
+// tokenize - splits the expression into numbers, words, dashes and pluses.
+//  Other symbols are skipped, except "$" which is a word.
+func tokenize(expression string) []token {
+	tokens := make([]token, 0)
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			value, err := strconv.ParseFloat(strings.TrimRight(string(runes[i:j]), "."), 64)
+			if err == nil {
+				tokens = append(tokens, token{kind: tokenNumber, value: value})
+			}
+			i = j
+		case unicode.IsLetter(r):
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenWord, word: strings.Trim(string(runes[i:j]), ".")})
+			i = j
+		case r == '-':
+			tokens = append(tokens, token{kind: tokenDash})
+			i++
+		case r == '+':
+			tokens = append(tokens, token{kind: tokenPlus})
+			i++
+		case r == '$':
+			tokens = append(tokens, token{kind: tokenWord, word: "$"})
+			i++
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// foldMultipliers - multiplies the numbers by the suffixes after them. The
+//  suffix of the last number applies to the small numbers before it without
+//  their own: "от 20 до 30 тыс" is 20000 - 30000.
+func foldMultipliers(tokens []token) []token {
+	folded := make([]token, 0, len(tokens))
+	for _, t := range tokens {
+		m := multiplier(t.word)
+		if t.kind != tokenWord || m == 0 {
+			folded = append(folded, t)
+			continue
+		}
+
+		if n := len(folded); n > 0 && folded[n-1].kind == tokenNumber && folded[n-1].multiplier == 0 {
+			folded[n-1].value *= m
+			folded[n-1].multiplier = m
+		}
+	}
+
+	last := -1
+	for i, t := range folded {
+		if t.kind == tokenNumber {
+			last = i
+		}
+	}
+	if last < 0 || folded[last].multiplier == 0 {
+		return folded
+	}
+
+	for i := 0; i < last; i++ {
+		if folded[i].kind == tokenNumber && folded[i].multiplier == 0 && folded[i].value < 1000 {
+			folded[i].value *= folded[last].multiplier
+			folded[i].multiplier = folded[last].multiplier
+		}
+	}
+	return folded
+}
+
+// multiplier - returns the multiplier for the suffix or 0
+func multiplier(word string) float64 {
+	switch word {
+	case "k", "к", "т", "тыс", "тысяч", "тысячи", "тысяча", "тысячу":
+		return 1000
+	case "m", "м", "млн", "миллион", "миллиона", "миллионов":
+		return 1000000
+	}
+	return 0
+}
+
+// currencyOf - returns the currency for the word or symbol or ""
+func currencyOf(word string) string {
+	switch {
+	case word == "$", word == "usd", word == "у.е", word == "уе",
+		strings.HasPrefix(word, "долл"), strings.HasPrefix(word, "бакс"), strings.HasPrefix(word, "dollar"):
+		return USD
+	case word == "kgs", word == "kgz", word == "с", word == "som", word == "soms",
+		strings.HasPrefix(word, "сом"):
+		return KGS
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/comov/hsearch/structs"
+)
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		text     string
+		currency string
+		want     []PriceRange
+		err      error
+	}{
+		{
+			text: "до 400$",
+			want: []PriceRange{{USD, structs.Price{0, 400}}},
+		},
+		{
+			text: "300-500 usd",
+			want: []PriceRange{{USD, structs.Price{300, 500}}},
+		},
+		{
+			text: "30к сом",
+			want: []PriceRange{{KGS, structs.Price{0, 30000}}},
+		},
+		{
+			text:     "от 25000",
+			currency: KGS,
+			want:     []PriceRange{{KGS, structs.Price{25000, 0}}},
+		},
+		{
+			text:     "25000+",
+			currency: KGS,
+			want:     []PriceRange{{KGS, structs.Price{25000, 0}}},
+		},
+		{
+			text:     "25 000 - 30 000",
+			currency: KGS,
+			want:     []PriceRange{{KGS, structs.Price{25000, 30000}}},
+		},
+		{
+			text:     "с 20 до 30 тыс",
+			currency: KGS,
+			want:     []PriceRange{{KGS, structs.Price{20000, 30000}}},
+		},
+		{
+			text: "до 30к сом или до 400$",
+			want: []PriceRange{
+				{KGS, structs.Price{0, 30000}},
+				{USD, structs.Price{0, 400}},
+			},
+		},
+		{
+			text: "300$-500$",
+			want: []PriceRange{{USD, structs.Price{300, 500}}},
+		},
+		{
+			text: "300 usd - 500 usd",
+			want: []PriceRange{{USD, structs.Price{300, 500}}},
+		},
+		{
+			text: "-1usd",
+			want: []PriceRange{{USD, structs.Price{-1, -1}}},
+		},
+		{
+			text:     "0",
+			currency: USD,
+			want:     []PriceRange{{USD, structs.Price{0, 0}}},
+		},
+		{
+			text:     "от 100 до 50",
+			currency: USD,
+			err:      ErrWrongRange,
+		},
+		{
+			text:     "недорого",
+			currency: USD,
+			err:      ErrNoPrice,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParsePrice(tt.text, tt.currency)
+			if err != tt.err {
+				t.Fatalf("ParsePrice(%q) error = %v, want %v", tt.text, err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePrice(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
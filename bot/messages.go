@@ -6,6 +6,7 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 
+	"github.com/comov/hsearch/scam"
 	"github.com/comov/hsearch/structs"
 )
 
@@ -15,15 +16,104 @@ const helpMessage = `
 Доступные команды:
 /help - справка по командам
 /settings - настройки и фильтры бота
+/filter - все фильтры одной строкой, например: /filter price=300-500usd rooms=2-3
+/profiles - несколько поисков с разными фильтрами в одном чате
+/profile - добавить или изменить поиск, например: /profile семья rooms=3
+/favorites - объявления, которые понравились
+/disliked - скрытые объявления, их можно вернуть
+/pipeline - объявления по статусам: позвонили, просмотр, сдано...
+/viewings - назначенные просмотры и календарь .ics
+/timezone - часовой пояс чата для просмотров, например: /timezone Asia/Bishkek
+/vote - когда скрывать объявление в группе: any, majority или all
+/blacklist - номера, от которых не показывать объявления
+/market - средние цены по районам для фильтров чата
 /feedback - отставить гневное сообщение автору 😐
 `
 
 const feedbackText = `Бот будет ждать от тебя сообщения примерно минут 5, после чего отправленный текст не будет считать фидбэком`
 const wrongAnswerText = `Ты что-то не так ввел. Посмотри пример и попробуй еще раз. Осталось попыток: %d`
 const somethingWrong = "Что-то пошло не так..."
+const unknownCommandText = "Нет среди доступных команд :("
+const unknownCallbackText = "Эта кнопка больше не работает"
+const photosFailedText = "Не получилось отправить фото: %d. Их можно посмотреть на сайте"
+const filterWrongText = "Не понял фильтр %s\n\nПример:\n%s"
+const filterCopyText = "Фильтры одной строкой, чтобы скопировать в другой чат:\n`/filter %s`"
+const profileLabelText = "🔎 Поиск: %s\n"
+const profileWrongNameText = "Название поиска - одно слово из букв, цифр и дефисов, например: /profile семья rooms=3"
+const profileNotFoundText = "Нет поиска с названием %s"
+const profileDeletedText = "Поиск %s удален"
+const profilesDisabledText = "Все поиски на паузе, квартиры ищутся по фильтрам чата (/filter)"
+const profilesEmptyText = "Поисков пока нет, квартиры ищутся по фильтрам чата (/filter). Добавить поиск:\n/profile семья rooms=3 price=до 800$"
+const likedText = "Добавил в избранное, список - /favorites"
+const unlikedText = "Убрал из избранного"
+const favoritesEmptyText = "В избранном пока пусто. Нажми «Нравится» под объявлением, чтобы сохранить его"
+const favoritesTitleText = "Избранное (%d):\n\n"
+const offerNotFoundText = "Предложение не найдено, возможно было удалено"
+const undoDislikeText = "Объявление скрыто. Почему? Это поможет настроить фильтры.\n\nПередумал? Отменить можно еще %d секунд, потом - в /disliked"
+const reasonSavedText = "Спасибо, учту"
+const suggestPriceText = "Уже %d раз «дорого». Искать в %s до %d?"
+const suggestPhotoText = "Уже %d раз «мало фото». Показывать только объявления с фото?"
+const suggestAgencyText = "Уже %d раз «агентство». Больше не показывать объявления от их номеров (%d)?"
+const suggestBlacklistedText = "Готово, номера в черном списке - /blacklist"
+const suggestAppliedText = "Готово, фильтр изменен. Все фильтры - /filter"
+const suggestDeclinedText = "Хорошо, оставлю как есть"
+const dislikedTitleText = "Последние скрытые объявления:\n\n"
+const dislikedEmptyText = "Скрытых объявлений нет"
+const restoredText = "Вернул объявление"
+const voteSavedText = "Голос учтен"
+const voteHiddenText = "Группа против, больше не покажу"
+const voteRuleText = "Объявление скрывается, когда 👎 %s. Изменить:\n/vote any - хватит одного голоса\n/vote majority - больше половины группы\n/vote all - вся группа"
+const voteRuleWrongText = "Не понял правило. Можно: /vote any, /vote majority или /vote all"
+const voteOnlyGroupText = "Голосование работает только в группах"
+const statusLabelText = "📌 Статус: %s\n"
+const statusSavedText = "Статус: %s"
+const pipelineEmptyText = "Пока пусто. Позвонили по объявлению - нажми «📌 Статус» под ним"
+const pipelineTitleText = "Объявления по статусам:\n"
+const viewingAskText = "Когда просмотр? Напиши дату и время (%s), например:\n25.10 18:30\nзавтра 19:00"
+const viewingPastText = "Эта дата уже прошла. Напиши дату и время просмотра еще раз"
+const viewingSavedText = "Записал просмотр на %s. Напомню за %s, все просмотры - /viewings"
+const viewingsEmptyText = "Просмотров пока нет. Нажми «📅 Назначить просмотр» под объявлением"
+const viewingsTitleText = "Ближайшие просмотры:\n\n"
+const viewingCanceledText = "Просмотр отменен"
+const viewingReminderText = "⏰ Скоро просмотр - %s\n\n%s\n"
+const timezoneText = "Часовой пояс чата: %s. Изменить:\n/timezone Asia/Bishkek\n/timezone +5"
+const timezoneWrongText = "Не знаю такой часовой пояс. Пример:\n/timezone Asia/Bishkek\n/timezone +5"
+const noteAskText = "Напиши заметку к объявлению следующим сообщением"
+const noteSavedText = "Сохранил заметку"
+const moreNotesText = "📝 …и ещё %d, все заметки в «Описание»"
+const snoozedText = "Спрятал, напомню %s"
+const snoozeGoneText = "Хотел напомнить об объявлении, но его уже сняли с сайта:\n%s\n%s"
+const reportSavedText = "Спасибо, передал модераторам"
+const reportAdminText = "⚠️ Жалоба: %s\nОт: %s (чат %d)\nЖалоб на объявление: %d\n\n%s\nНомер: %s\n%s"
+const reportAutoHiddenText = "\n\n🙈 Скрыто автоматически: жалоб %d из %d"
+const reportResolvedText = "\n\n✅ %s"
+const blockedText = "Больше не покажу объявления с номера %s, список - /blacklist"
+const blockNoPhoneText = "В объявлении нет номера"
+const blacklistEmptyText = "Черный список пуст. Нажми «🚫 Не показывать от этого номера» под объявлением"
+const blacklistTitleText = "Номера, от которых не показываю объявления:\n\n"
+const blacklistGlobalTitleText = "Общий черный список, для всех чатов:\n\n"
+const unblockedText = "Убрал номер из черного списка"
+const banWrongText = "Номер не похож на кыргызский. Пример:\n/ban +996 555 123456 агентство"
+const bannedText = "Номер %s в общем черном списке. Все номера - /blacklist global"
+const unbannedText = "Номер %s убран из общего черного списка"
+const suspiciousText = "🚩 Осторожно, похоже на мошенников: %s. Не платите до просмотра\n"
+const samePhotosText = "⚠️ Такие же фото есть в других объявлениях: %d\n"
+const marketCheaperText = "📊 На %d%% дешевле медианы по району (%d %s)\n"
+const marketDearerText = "📊 На %d%% дороже медианы по району (%d %s)\n"
+const marketMedianText = "📊 Цена около медианы по району (%d %s)\n"
+const marketEmptyText = "Пока мало объявлений, чтобы посчитать цены для твоих фильтров. Загляни позже"
+const marketTitleText = "Цены за последние %d дн., медиана и половина объявлений между 25%% и 75%%:\n"
 
 func DefaultMessage(offer *structs.Offer) string {
 	var message strings.Builder
+	if offer.Profile != "" {
+		message.WriteString(fmt.Sprintf(profileLabelText, offer.Profile))
+	}
+
+	if offer.Status != "" && offer.Status != structs.StatusNew {
+		message.WriteString(fmt.Sprintf(statusLabelText, statusName(offer.Status)))
+	}
+
 	message.WriteString(offer.Topic)
 	message.WriteString("\n\n")
 
@@ -41,6 +131,8 @@ func DefaultMessage(offer *structs.Offer) string {
 		message.WriteString("\n")
 	}
 
+	message.WriteString(marketText(offer))
+
 	if offer.Floor != "" {
 		message.Grow(len("Этаж: ") + len(offer.Floor) + len("\n"))
 		message.WriteString("Этаж: ")
@@ -69,10 +161,41 @@ func DefaultMessage(offer *structs.Offer) string {
 		message.WriteString("\n")
 	}
 
+	if offer.SamePhotos != 0 {
+		message.WriteString(fmt.Sprintf(samePhotosText, offer.SamePhotos))
+	}
+
+	if offer.ScamScore >= scam.Suspicious {
+		message.WriteString(fmt.Sprintf(suspiciousText, scamReasonsText(offer.ScamReasons)))
+	}
+
 	message.Grow(len("\n") + len(offer.Url) + len("\n"))
 	message.WriteString("\n")
 	message.WriteString(offer.Url)
 	message.WriteString("\n")
+
+	if len(offer.Links) != 0 {
+		message.WriteString("\nЭто же объявление:\n")
+		for _, link := range offer.Links {
+			message.WriteString(link)
+			message.WriteString("\n")
+		}
+	}
+
+	if len(offer.Notes) != 0 {
+		message.WriteString("\n")
+		message.WriteString(markdownReplacer.Replace(cardNotesText(offer.Notes)))
+	}
+
+	up, down := voters(offer.Votes)
+	if len(up) != 0 {
+		message.WriteString("\n👍 ")
+		message.WriteString(strings.Join(up, ", "))
+	}
+	if len(down) != 0 {
+		message.WriteString("\n👎 ")
+		message.WriteString(strings.Join(down, ", "))
+	}
 	return message.String()
 }
 
@@ -109,3 +232,150 @@ func getFeedbackAdminText(chat *tgbotapi.Chat, text string) string {
 	msg += fmt.Sprintf("Оставил feedback:\n%s", text)
 	return msg
 }
+
+// voteRuleName - the vote rule as people say it
+func voteRuleName(rule string) string {
+	switch rule {
+	case structs.VoteMajority:
+		return "поставило больше половины группы"
+	case structs.VoteAll:
+		return "поставили все"
+	}
+	return "поставил хотя бы один"
+}
+
+// voters - names of the members who voted 👍 and 👎
+func voters(votes []*structs.Vote) ([]string, []string) {
+	up, down := make([]string, 0), make([]string, 0)
+	for _, vote := range votes {
+		if vote.Value > 0 {
+			up = append(up, vote.Username)
+		} else {
+			down = append(down, vote.Username)
+		}
+	}
+	return up, down
+}
+
+// statusName - the status of the offer in the pipeline as people say it
+func statusName(status string) string {
+	switch status {
+	case structs.StatusContacted:
+		return "позвонили"
+	case structs.StatusViewing:
+		return "назначен просмотр"
+	case structs.StatusViewed:
+		return "посмотрели"
+	case structs.StatusRejected:
+		return "отказ"
+	case structs.StatusRented:
+		return "сдано"
+	}
+	return "новое"
+}
+
+// notesText - the notes of the chat about the offer, one per line
+func notesText(notes []*structs.Note) string {
+	lines := make([]string, 0, len(notes))
+	for _, note := range notes {
+		lines = append(lines, fmt.Sprintf("📝 %s: %s", note.Username, note.Body))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// the card keeps the last notes and cuts the long ones, otherwise the card
+//  does not fit into the message of telegram
+const (
+	cardNotes      = 3
+	cardNoteLength = 200
+
+	// maxMessageLength - the limit of the message text in telegram
+	maxMessageLength = 4096
+)
+
+// cardNotesText - the last notes of the chat for the card, all of them are
+//  shown with the description
+func cardNotesText(notes []*structs.Note) string {
+	hidden := 0
+	if len(notes) > cardNotes {
+		hidden = len(notes) - cardNotes
+		notes = notes[hidden:]
+	}
+
+	lines := make([]string, 0, len(notes)+1)
+	if hidden != 0 {
+		lines = append(lines, fmt.Sprintf(moreNotesText, hidden))
+	}
+	for _, note := range notes {
+		body := []rune(note.Body)
+		if len(body) > cardNoteLength {
+			body = append(body[:cardNoteLength-1], '…')
+		}
+		lines = append(lines, fmt.Sprintf("📝 %s: %s", note.Username, string(body)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitMessage - splits the long text into messages by lines, the line
+//  longer than the limit is split by runes
+func splitMessage(text string) []string {
+	messages := make([]string, 0, 1)
+	var message []rune
+	for _, line := range strings.SplitAfter(text, "\n") {
+		runes := []rune(line)
+		if len(message)+len(runes) > maxMessageLength && len(message) != 0 {
+			messages = append(messages, string(message))
+			message = nil
+		}
+		for len(runes) > maxMessageLength {
+			messages = append(messages, string(runes[:maxMessageLength]))
+			runes = runes[maxMessageLength:]
+		}
+		message = append(message, runes...)
+	}
+	if len(message) != 0 || len(messages) == 0 {
+		messages = append(messages, string(message))
+	}
+	return messages
+}
+
+// scamReasonsText - why the offer looks like a fraud
+func scamReasonsText(reasons []string) string {
+	texts := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		switch reason {
+		case scam.ReasonLowPrice:
+			texts = append(texts, "цена сильно ниже похожих")
+		case scam.ReasonPrepayment:
+			texts = append(texts, "просят предоплату")
+		case scam.ReasonNoPhotos:
+			texts = append(texts, "нет фото")
+		case scam.ReasonReported:
+			texts = append(texts, "на номер жаловались")
+		case scam.ReasonStockPhoto:
+			texts = append(texts, "фото из чужих объявлений")
+		}
+	}
+	return strings.Join(texts, ", ")
+}
+
+// marketEqualPercent - the difference with the median which is not shown
+const marketEqualPercent = 5
+
+// marketText - how the price differs from the median price of the same flats
+//  in the district
+func marketText(offer *structs.Offer) string {
+	if offer.MarketMedian == 0 || offer.Price == 0 {
+		return ""
+	}
+
+	currency := strings.ToUpper(offer.Currency)
+	percent := (offer.Price - offer.MarketMedian) * 100 / offer.MarketMedian
+	switch {
+	case percent <= -marketEqualPercent:
+		return fmt.Sprintf(marketCheaperText, -percent, offer.MarketMedian, currency)
+	case percent >= marketEqualPercent:
+		return fmt.Sprintf(marketDearerText, percent, offer.MarketMedian, currency)
+	}
+	return fmt.Sprintf(marketMedianText, offer.MarketMedian, currency)
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/comov/hsearch/structs"
+)
+
+func TestCardNotesText(t *testing.T) {
+	notes := make([]*structs.Note, 0, 5)
+	for i := 1; i <= 5; i++ {
+		notes = append(notes, &structs.Note{Username: "Аня", Body: fmt.Sprintf("заметка %d", i)})
+	}
+	notes[4].Body = strings.Repeat("я", 1000)
+
+	lines := strings.Split(cardNotesText(notes), "\n")
+	if len(lines) != cardNotes+1 {
+		t.Fatalf("cardNotesText() has %d lines, want %d", len(lines), cardNotes+1)
+	}
+	if want := fmt.Sprintf(moreNotesText, 2); lines[0] != want {
+		t.Errorf("first line = %q, want %q", lines[0], want)
+	}
+	if want := "📝 Аня: заметка 3"; lines[1] != want {
+		t.Errorf("second line = %q, want %q", lines[1], want)
+	}
+	prefix := utf8.RuneCountInString("📝 Аня: ")
+	if n := utf8.RuneCountInString(lines[3]); n != prefix+cardNoteLength {
+		t.Errorf("long note has %d runes, want %d", n, prefix+cardNoteLength)
+	}
+	if !strings.HasSuffix(lines[3], "…") {
+		t.Errorf("long note is not cut: %q", lines[3])
+	}
+
+	if got := cardNotesText(notes[:1]); got != "📝 Аня: заметка 1" {
+		t.Errorf("cardNotesText() = %q", got)
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	line := strings.Repeat("а", 99) + "\n"
+	tests := []struct {
+		name     string
+		text     string
+		messages int
+	}{
+		{"empty", "", 1},
+		{"short", "описание", 1},
+		{"exactly the limit", strings.Repeat("а", maxMessageLength), 1},
+		{"by lines", strings.Repeat(line, 100), 3},
+		{"long line", strings.Repeat("а", maxMessageLength*2+1), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := splitMessage(tt.text)
+			if len(messages) != tt.messages {
+				t.Errorf("splitMessage() has %d messages, want %d", len(messages), tt.messages)
+			}
+			for i, message := range messages {
+				if n := utf8.RuneCountInString(message); n > maxMessageLength {
+					t.Errorf("message %d has %d runes", i, n)
+				}
+			}
+			if joined := strings.Join(messages, ""); joined != tt.text {
+				t.Error("joined messages differ from the text")
+			}
+		})
+	}
+}
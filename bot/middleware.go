@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// slowRequest - requests longer than that are logged as slow
+const slowRequest = time.Second * 5
+
+// recoverMiddleware - a panic in the handler must not kill the bot. The
+//  panic is sent to sentry and the user gets "something went wrong".
+func (b *Bot) recoverMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+
+			sentry.CurrentHub().Recover(err)
+			log.Printf("[router.recover] %s panic: %v\n%s", req.Route, err, debug.Stack())
+
+			if req.Callback != nil {
+				_, _ = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(req.Callback.ID, somethingWrong))
+				return
+			}
+			if req.Chat != nil {
+				_, _ = b.Send(tgbotapi.NewMessage(req.Chat.ID, somethingWrong))
+			}
+		}()
+
+		next(ctx, req)
+	}
+}
+
+// logMiddleware - logs every routed request
+func (b *Bot) logMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) {
+		chatId := int64(0)
+		if req.Chat != nil {
+			chatId = req.Chat.ID
+		}
+		log.Printf("[router] %s in chat %d\n", req.Route, chatId)
+		next(ctx, req)
+	}
+}
+
+// timingMiddleware - logs the requests which took too long
+func (b *Bot) timingMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) {
+		start := time.Now()
+		next(ctx, req)
+
+		if duration := time.Since(start); duration > slowRequest {
+			sentry.AddBreadcrumb(&sentry.Breadcrumb{
+				Category: "router",
+				Message:  fmt.Sprintf("%s took %s", req.Route, duration),
+			})
+			log.Printf("[router] %s is slow: %s\n", req.Route, duration)
+		}
+	}
+}
+
+// adminOnly - the route works only in the admin chat or for the admin. For
+//  the others it looks like an unknown command.
+func (b *Bot) adminOnly(next Handler) Handler {
+	return func(ctx context.Context, req *Request) {
+		if b.isAdmin(req) {
+			next(ctx, req)
+			return
+		}
+
+		log.Printf("[router.adminOnly] %s is denied\n", req.Route)
+		if req.Callback != nil {
+			_, _ = b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(req.Callback.ID, unknownCommandText))
+			return
+		}
+		b.unknownCommand(ctx, req)
+	}
+}
+
+func (b *Bot) isAdmin(req *Request) bool {
+	if b.adminChatId == 0 {
+		return false
+	}
+
+	if req.Chat != nil && req.Chat.ID == b.adminChatId {
+		return true
+	}
+
+	if req.Callback != nil && req.Callback.From != nil {
+		return int64(req.Callback.From.ID) == b.adminChatId
+	}
+
+	if req.Message != nil && req.Message.From != nil {
+		return int64(req.Message.From.ID) == b.adminChatId
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+type (
+	// Request - an update from a private chat, a group or a channel. Message
+	//  is the message or the channel post, Callback is the pressed button.
+	Request struct {
+		Update   tgbotapi.Update
+		Message  *tgbotapi.Message
+		Callback *tgbotapi.CallbackQuery
+		Chat     *tgbotapi.Chat
+
+		// Route - the command or the callback the request was routed to
+		Route string
+	}
+
+	// Handler - processes the request
+	Handler func(ctx context.Context, req *Request)
+
+	// Middleware - wraps the handler, for example to recover from panic or
+	//  to check permissions
+	Middleware func(next Handler) Handler
+
+	prefixRoute struct {
+		prefix  string
+		handler Handler
+	}
+
+	// router - finds the handler for the update by the command or by the
+	//  callback data. Callbacks are matched by the exact data, then by the
+	//  action of the offer button, then by the registered prefixes.
+	router struct {
+		middlewares []Middleware
+		commands    map[string]Handler
+		callbacks   map[string]Handler
+		prefixes    []prefixRoute
+
+		text            Handler
+		unknownCommand  Handler
+		unknownCallback Handler
+	}
+)
+
+func newRouter() *router {
+	return &router{
+		commands:  make(map[string]Handler),
+		callbacks: make(map[string]Handler),
+	}
+}
+
+// Use - adds middlewares for all routes. Must be called before the routes
+//  registration.
+func (r *router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// Command - registers the handler for the /command
+func (r *router) Command(name string, h Handler, middlewares ...Middleware) {
+	r.commands[name] = r.wrap(h, middlewares)
+}
+
+// Callback - registers the handler for the callback data or for the action
+//  of the offer button
+func (r *router) Callback(data string, h Handler, middlewares ...Middleware) {
+	r.callbacks[data] = r.wrap(h, middlewares)
+}
+
+// CallbackPrefix - registers the handler for all callbacks with the prefix,
+//  for example `favorites:` for `favorites:2`
+func (r *router) CallbackPrefix(prefix string, h Handler, middlewares ...Middleware) {
+	r.prefixes = append(r.prefixes, prefixRoute{prefix: prefix, handler: r.wrap(h, middlewares)})
+}
+
+// Text - registers the handler for the messages which are not commands
+func (r *router) Text(h Handler, middlewares ...Middleware) {
+	r.text = r.wrap(h, middlewares)
+}
+
+// NotFound - registers the handlers for the unknown commands and callbacks
+func (r *router) NotFound(command, callback Handler) {
+	r.unknownCommand = r.wrap(command, nil)
+	r.unknownCallback = r.wrap(callback, nil)
+}
+
+// Dispatch - routes the update to the handler
+func (r *router) Dispatch(ctx context.Context, update tgbotapi.Update) {
+	req := &Request{Update: update}
+
+	switch {
+	case update.CallbackQuery != nil:
+		req.Callback = update.CallbackQuery
+		if req.Callback.Message != nil {
+			req.Message = req.Callback.Message
+			req.Chat = req.Callback.Message.Chat
+		}
+		req.Route = callbackAction(req.Callback.Data)
+		r.call(ctx, req, r.findCallback(req.Callback.Data), r.unknownCallback)
+	case update.Message != nil:
+		req.Message = update.Message
+		req.Chat = update.Message.Chat
+		r.dispatchMessage(ctx, req)
+	case update.ChannelPost != nil:
+		req.Message = update.ChannelPost
+		req.Chat = update.ChannelPost.Chat
+		if req.Message.IsCommand() {
+			r.dispatchMessage(ctx, req)
+		}
+	}
+}
+
+func (r *router) dispatchMessage(ctx context.Context, req *Request) {
+	if !req.Message.IsCommand() {
+		req.Route = "text"
+		r.call(ctx, req, r.text, nil)
+		return
+	}
+
+	req.Route = req.Message.Command()
+	r.call(ctx, req, r.commands[req.Route], r.unknownCommand)
+}
+
+// findCallback - returns the handler for the callback data or nil
+func (r *router) findCallback(data string) Handler {
+	if h, ok := r.callbacks[data]; ok {
+		return h
+	}
+
+	if od, ok := parseOfferCallback(data); ok {
+		if h, ok := r.callbacks[od.action]; ok {
+			return h
+		}
+	}
+
+	for _, p := range r.prefixes {
+		if strings.HasPrefix(data, p.prefix) {
+			return p.handler
+		}
+	}
+	return nil
+}
+
+func (r *router) call(ctx context.Context, req *Request, h, notFound Handler) {
+	if h == nil {
+		h = notFound
+	}
+	if h != nil {
+		h(ctx, req)
+	}
+}
+
+// wrap - the route middlewares are called after the common ones
+func (r *router) wrap(h Handler, middlewares []Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		h = r.middlewares[i](h)
+	}
+	return h
+}
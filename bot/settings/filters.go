@@ -27,19 +27,22 @@ var (
 func MainFiltersHandler(msg *tgbotapi.Message, chat *structs.Chat) tgbotapi.Chattable {
 	msgText := fmt.Sprintf(mainFiltersText,
 		yesNo(chat.Photo),
+		rooms(chat.Rooms),
 		price(chat.KGS),
 		price(chat.USD),
+		yesNo(chat.HideSuspicious),
 	)
 
 	message := tgbotapi.NewEditMessageText(msg.Chat.ID, msg.MessageID, msgText)
 	message.ReplyMarkup = getFiltersKeyboard(
 		chat.Photo,
+		chat.HideSuspicious,
 	)
 	message.ParseMode = tgbotapi.ModeMarkdown
 	return message
 }
 
-func getFiltersKeyboard(photo bool) *tgbotapi.InlineKeyboardMarkup {
+func getFiltersKeyboard(photo, hideSuspicious bool) *tgbotapi.InlineKeyboardMarkup {
 	text := "Только с фото"
 	data := "withPhotoOn"
 	if photo {
@@ -47,10 +50,20 @@ func getFiltersKeyboard(photo bool) *tgbotapi.InlineKeyboardMarkup {
 		data = "withPhotoOff"
 	}
 
+	suspiciousText := "Скрывать подозрительные"
+	suspiciousData := "suspiciousOn"
+	if hideSuspicious {
+		suspiciousText = "Показывать подозрительные"
+		suspiciousData = "suspiciousOff"
+	}
+
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(text, data),
 		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(suspiciousText, suspiciousData),
+		),
 		pricesRow,
 		backRow,
 	)
@@ -1,8 +1,7 @@
 package settings
 
 import (
-	"fmt"
-
+	"github.com/comov/hsearch/bot/input"
 	"github.com/comov/hsearch/structs"
 )
 
@@ -20,25 +19,33 @@ const mainSearchText = `*Основные настройки поиска*
 
 const mainFiltersText = `*Фильтры поиска*
 Только с фото: %s
+Комнат: %s
 Цена в KGS: %s
-Цена в USD: %s`
+Цена в USD: %s
+Скрывать подозрительные: %s`
 
 // filter price text
 const (
-	textKGS = `Укажите суммы в сомах, через дефис в пределах которых нужно искать.
+	textKGS = `Напишите, в пределах каких сумм в сомах нужно искать.
 
 (0 - любая цена / -1 не искать в KGS)
 (бот ждет ответа около минуты, потом забывает изменить этот фильтр)
 
-Пример:
-10000 - 20000`
-	textUSD = `Укажите суммы в долларах, через дефис в пределах которых нужно искать.
+Примеры:
+10000 - 20000
+от 25000
+до 30к сом
+до 30к сом или до 400$`
+	textUSD = `Напишите, в пределах каких сумм в долларах нужно искать.
 
 (бот ждет ответа около минуты, потом забывает изменить этот фильтр)
 (0 - любая цена / -1 не искать в USD)
 
-Пример:
-250 - 350`
+Примеры:
+250 - 350
+до 400$
+300-500 usd
+до 400$ или до 30к сом`
 )
 
 func yesNo(v bool) string {
@@ -49,5 +56,9 @@ func yesNo(v bool) string {
 }
 
 func price(prices structs.Price) string {
-	return fmt.Sprintf("%d - %d", prices[0], prices[1])
+	return input.FormatPrice(prices)
+}
+
+func rooms(rooms structs.Price) string {
+	return input.FormatRooms(rooms)
 }
@@ -14,7 +14,22 @@ import (
 var BackFlowMap = map[string]string{
 	"Фильтры поиска":            "settings",
 	"Основные настройки поиска": "settings",
-	"Укажите суммы в":           "filters",
+	"в пределах каких сумм":     "filters",
+}
+
+// Summary - all settings and filters of the chat as text in markdown
+func Summary(chat *structs.Chat) string {
+	return fmt.Sprintf(mainSettingsText,
+		yesNo(chat.Enable),
+		yesNo(chat.Diesel),
+		yesNo(chat.House),
+		yesNo(chat.Lalafo),
+		yesNo(chat.Photo),
+		rooms(chat.Rooms),
+		price(chat.KGS),
+		price(chat.USD),
+		yesNo(chat.HideSuspicious),
+	)
 }
 
 // buttons for configs
@@ -28,15 +43,7 @@ var (
 )
 
 func MainSettingsHandler(msg *tgbotapi.Message, chat *structs.Chat) tgbotapi.Chattable {
-	msgText := fmt.Sprintf(mainSettingsText,
-		yesNo(chat.Enable),
-		yesNo(chat.Diesel),
-		yesNo(chat.House),
-		yesNo(chat.Lalafo),
-		yesNo(chat.Photo),
-		price(chat.KGS),
-		price(chat.USD),
-	)
+	msgText := Summary(chat)
 
 	if msg.IsCommand() {
 		message := tgbotapi.NewMessage(msg.Chat.ID, msgText)
@@ -1,13 +1,14 @@
 package bot
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"mime/multipart"
 	"net/url"
 	"strconv"
-	"time"
 
 	"github.com/go-telegram-bot-api/telegram-bot-api"
 
@@ -33,6 +34,58 @@ func (b *Bot) SendGroupPhotos(config tgbotapi.MediaGroupConfig) ([]tgbotapi.Mess
 	return messages, err
 }
 
+// UploadGroupPhotos - like SendGroupPhotos, but the photos are uploaded in
+//  the request body. The media of the config must refer to the files as
+//  attach://<name>.
+func (b *Bot) UploadGroupPhotos(config tgbotapi.MediaGroupConfig, files map[string][]byte) ([]tgbotapi.Message, error) {
+	params, err := buildParams(config)
+	if err != nil {
+		return []tgbotapi.Message{}, err
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key := range params {
+		if err := writer.WriteField(key, params.Get(key)); err != nil {
+			return []tgbotapi.Message{}, err
+		}
+	}
+
+	for name, data := range files {
+		part, err := writer.CreateFormFile(name, name+".jpg")
+		if err != nil {
+			return []tgbotapi.Message{}, err
+		}
+		if _, err := part.Write(data); err != nil {
+			return []tgbotapi.Message{}, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return []tgbotapi.Message{}, err
+	}
+
+	endpoint := fmt.Sprintf(tgbotapi.APIEndpoint, b.bot.Token, "sendMediaGroup")
+	res, err := b.bot.Client.Post(endpoint, writer.FormDataContentType(), &body)
+	if err != nil {
+		return []tgbotapi.Message{}, err
+	}
+	defer res.Body.Close()
+
+	var resp tgbotapi.APIResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return []tgbotapi.Message{}, err
+	}
+
+	if !resp.Ok {
+		return []tgbotapi.Message{}, errors.New(resp.Description)
+	}
+
+	var messages []tgbotapi.Message
+	err = json.Unmarshal(resp.Result, &messages)
+	return messages, err
+}
+
 func buildParams(config tgbotapi.MediaGroupConfig) (url.Values, error) {
 	chat := config.BaseChat
 	v := url.Values{}
@@ -75,7 +128,7 @@ func (b *Bot) SendOffer(ctx context.Context, offer *structs.Offer, chat *structs
 	message.ParseMode = tgbotapi.ModeMarkdown
 
 	if !chat.IsChannel() {
-		message.ReplyMarkup = getKeyboard(offer)
+		message.ReplyMarkup = getKeyboard(offer, chat.IsGroup())
 	}
 
 	msg, err := b.Send(message)
@@ -83,7 +136,7 @@ func (b *Bot) SendOffer(ctx context.Context, offer *structs.Offer, chat *structs
 		return err
 	}
 
-	err = b.storage.SaveMessage(ctx, msg.MessageID, offer.Id, chat.Id, structs.KindOffer)
+	err = b.storage.SaveMessage(ctx, msg.MessageID, offer.Id, chat.Id, structs.KindOffer, offer.Profile)
 	if err != nil {
 		return err
 	}
@@ -93,6 +146,7 @@ func (b *Bot) SendOffer(ctx context.Context, offer *structs.Offer, chat *structs
 	}
 
 	query := &tgbotapi.CallbackQuery{
+		Data: offerCallbackData("photo", offer),
 		Message: &tgbotapi.Message{
 			MessageID: msg.MessageID,
 			Chat: &tgbotapi.Chat{
@@ -105,51 +159,27 @@ func (b *Bot) SendOffer(ctx context.Context, offer *structs.Offer, chat *structs
 	return nil
 }
 
-func (b *Bot) addWaitCallback(c int64, answer answer) {
-	b.waitMutex.Lock()
-	defer b.waitMutex.Unlock()
-	b.waitAnswers[c] = answer
-}
-
-func (b *Bot) wrongAnswer(ctx context.Context, message *tgbotapi.Message, a answer) {
-	a.maxErrors -= 1
-	a.deadline = time.Now().Add(time.Second * 20)
-	if a.maxErrors <= 0 {
-		b.clearRetry(ctx, message.Chat, message.MessageID)
-		return
-	}
-
-	newMessage := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(wrongAnswerText, a.maxErrors))
-	m, err := b.Send(newMessage)
-	if err != nil {
-		b.waitAnswers[message.Chat.ID] = a
-		log.Println("[wrongAnswer.Send] error:", err)
-		return
-	}
-
-	a.messages = append(a.messages, message.MessageID, m.MessageID)
-	b.waitAnswers[message.Chat.ID] = a
-}
-
-func (b *Bot) clearRetry(ctx context.Context, chat *tgbotapi.Chat, lastMsgId int) {
-	a := b.waitAnswers[chat.ID]
-	if lastMsgId != -1 {
-		a.messages = append(a.messages, lastMsgId)
-	}
-	for _, id := range a.messages {
-		deleteMessage := tgbotapi.NewDeleteMessage(chat.ID, id)
-		_, err := b.Send(deleteMessage)
-		if err != nil {
-			log.Println("[clearRetry.Send] error:", err)
+// sendList - sends the list with the buttons or, if messageId is set, shows
+//  it in the message instead of the previous page
+func (b *Bot) sendList(chatId int64, messageId int, text string, rows [][]tgbotapi.InlineKeyboardButton) error {
+	var message tgbotapi.Chattable
+	if messageId != 0 {
+		edit := tgbotapi.NewEditMessageText(chatId, messageId, text)
+		edit.DisableWebPagePreview = true
+		if len(rows) != 0 {
+			keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+			edit.ReplyMarkup = &keyboard
 		}
+		message = edit
+	} else {
+		msg := tgbotapi.NewMessage(chatId, text)
+		msg.DisableWebPagePreview = true
+		if len(rows) != 0 {
+			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		}
+		message = msg
 	}
 
-	if a.menuId != 0 {
-		b.callbacks["filters"](ctx, &tgbotapi.CallbackQuery{Message: &tgbotapi.Message{
-			Chat:      chat,
-			MessageID: a.menuId,
-		}})
-	}
-
-	delete(b.waitAnswers, chat.ID)
+	_, err := b.Send(message)
+	return err
 }
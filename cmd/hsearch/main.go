@@ -73,6 +73,9 @@ func main() {
 	go bgm.StartGarbageCollector()
 	go bgm.StartGrabber()
 	go bgm.StartMatcher()
+	go bgm.StartReminder()
+	go bgm.StartSnoozeChecker()
+	go bgm.StartStatistics()
 	go bgm.StartApi()
 
 	telegramBot.Start()
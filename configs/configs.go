@@ -21,9 +21,38 @@ type Config struct {
 	PgHost          string `env:"GO_DB_HOST"`
 	PgPort          int32  `env:"GO_DB_PORT"`
 	HTTPBind        string `env:"HTTP_BIND"`
+	ViewingRemind   string `env:"VIEWING_REMIND"`
+	ReportThreshold int    `env:"REPORT_THRESHOLD"`
+	ArchiveDays     int    `env:"ARCHIVE_DAYS"`
+	MarketDays      int    `env:"MARKET_DAYS"`
 
-	FrequencyTime time.Duration
-	RelevanceTime time.Duration
+	GarbageFrequency   string `env:"GARBAGE_FREQUENCY"`
+	OfferExpireDays    int    `env:"OFFER_EXPIRE_DAYS"`
+	ImageExpireDays    int    `env:"IMAGE_EXPIRE_DAYS"`
+	AnswerExpireDays   int    `env:"ANSWER_EXPIRE_DAYS"`
+	MessageExpireDays  int    `env:"MESSAGE_EXPIRE_DAYS"`
+	FeedbackExpireDays int    `env:"FEEDBACK_EXPIRE_DAYS"`
+	VoteExpireDays     int    `env:"VOTE_EXPIRE_DAYS"`
+	ViewingExpireDays  int    `env:"VIEWING_EXPIRE_DAYS"`
+	NoteExpireDays     int    `env:"NOTE_EXPIRE_DAYS"`
+	SnoozeExpireDays   int    `env:"SNOOZE_EXPIRE_DAYS"`
+	StatusExpireDays   int    `env:"STATUS_EXPIRE_DAYS"`
+	ReportExpireDays   int    `env:"REPORT_EXPIRE_DAYS"`
+
+	ImageStorage   string `env:"IMAGE_STORAGE"`
+	ImageDir       string `env:"IMAGE_DIR"`
+	ImagePublicUrl string `env:"IMAGE_PUBLIC_URL"`
+	ImageLimit     int    `env:"IMAGE_LIMIT"`
+	S3Endpoint     string `env:"S3_ENDPOINT"`
+	S3Region       string `env:"S3_REGION"`
+	S3Bucket       string `env:"S3_BUCKET"`
+	S3AccessKey    string `env:"S3_ACCESS_KEY"`
+	S3SecretKey    string `env:"S3_SECRET_KEY"`
+
+	FrequencyTime     time.Duration
+	RelevanceTime     time.Duration
+	ViewingRemindTime time.Duration
+	GarbageTime       time.Duration
 
 	ExpireDays   int
 	PgConnString string
@@ -38,7 +67,28 @@ func GetConf() (*Config, error) {
 		PgHost:          "localhost",
 		PgPort:          5432,
 		HTTPBind:        ":3300",
+		ViewingRemind:   "2h",
+		ReportThreshold: 3,
+		ArchiveDays:     365,
+		MarketDays:      30,
 		ExpireDays:      7,
+		ImageStorage:    "local",
+		ImageDir:        "images",
+		ImageLimit:      20,
+		S3Region:        "us-east-1",
+
+		GarbageFrequency:   "6h",
+		OfferExpireDays:    7,
+		ImageExpireDays:    7,
+		AnswerExpireDays:   7,
+		MessageExpireDays:  7,
+		FeedbackExpireDays: 365,
+		VoteExpireDays:     7,
+		ViewingExpireDays:  7,
+		NoteExpireDays:     7,
+		SnoozeExpireDays:   7,
+		StatusExpireDays:   30,
+		ReportExpireDays:   30,
 	}
 
 	err := env.Parse(cfg)
@@ -69,6 +119,21 @@ func GetConf() (*Config, error) {
 		return nil, err
 	}
 
+	// ViewingRemindTime - how long before the viewing to remind about it
+	cfg.ViewingRemindTime, err = time.ParseDuration(cfg.ViewingRemind)
+	if err != nil {
+		return nil, err
+	}
+
+	// GarbageTime - how often the old records are cleaned
+	cfg.GarbageTime, err = time.ParseDuration(cfg.GarbageFrequency)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.GarbageTime <= 0 {
+		return nil, fmt.Errorf("GARBAGE_FREQUENCY must be positive, got %s", cfg.GarbageFrequency)
+	}
+
 	cfg.PgConnString = fmt.Sprintf("user=hsearch password=%s host=%s port=%d dbname=hsearch",
 		cfg.PgPassword,
 		cfg.PgHost,
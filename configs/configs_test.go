@@ -0,0 +1,36 @@
+package configs
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGetConfGarbageFrequency(t *testing.T) {
+	defer os.Unsetenv("GARBAGE_FREQUENCY")
+
+	tests := []struct {
+		frequency string
+		err       bool
+	}{
+		{"6h", false},
+		{"30m", false},
+		{"0s", true},
+		{"-1h", true},
+		{"often", true},
+	}
+
+	for _, tt := range tests {
+		if err := os.Setenv("GARBAGE_FREQUENCY", tt.frequency); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := GetConf()
+		if (err != nil) != tt.err {
+			t.Errorf("GetConf() with %q error = %v, want error %v", tt.frequency, err, tt.err)
+			continue
+		}
+		if err == nil && cfg.SnoozeExpireDays != 7 {
+			t.Errorf("SnoozeExpireDays = %d, want the default 7", cfg.SnoozeExpireDays)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package dedup - the same flat is often posted on several sites at once (or
+//  re-posted on the same site), so after parsing we group such offers into
+//  clusters and the user gets only one card for the whole cluster.
+package dedup
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/comov/hsearch/structs"
+)
+
+const (
+	// strongSimilarity - the texts are almost the same, it is enough to have
+	//  the same price or the same phone to call it a duplicate
+	strongSimilarity = 0.8
+
+	// weakSimilarity - the phone, price, rooms and area are the same, so the
+	//  texts can differ a lot (different sites have different templates)
+	weakSimilarity = 0.3
+)
+
+var (
+	notDigitRegex = regexp.MustCompile(`\D+`)
+	intRegex      = regexp.MustCompile(`\d+`)
+)
+
+// NormalizePhone - leads the phone to the +996XXXXXXXXX format, so the same
+//  number written differently on different sites can be compared.
+func NormalizePhone(phone string) string {
+	digits := notDigitRegex.ReplaceAllString(phone, "")
+	if len(digits) < 9 {
+		return ""
+	}
+	return "+996" + digits[len(digits)-9:]
+}
+
+// Prepare - fills the fields needed to search for duplicates
+func Prepare(offer *structs.Offer) {
+	offer.PhoneNorm = NormalizePhone(offer.Phone)
+	offer.Signature = MinHash(offer.Body)
+}
+
+// IsDuplicate - checks that two offers are about the same flat
+func IsDuplicate(a, b *structs.Offer) bool {
+	if a.Id == b.Id && a.Site == b.Site {
+		return false
+	}
+
+	sameContacts := a.PhoneNorm != "" && a.PhoneNorm == b.PhoneNorm
+	samePrice := a.Price != 0 && a.Price == b.Price && a.Currency == b.Currency
+	similarity := Similarity(a.Signature, b.Signature)
+
+	if similarity >= strongSimilarity {
+		return samePrice || sameContacts
+	}
+
+	// agencies reuse photos for different flats, so the same photos alone
+	//  are not enough
+	samePhotos := SamePhotos(a.PhotoHashes(), b.PhotoHashes()) >= minSamePhotos
+	if samePhotos && (samePrice || sameContacts || similarity >= weakSimilarity) {
+		return true
+	}
+
+	if !sameContacts || !samePrice {
+		return false
+	}
+
+	if !equalOrBlank(firstInt(a.Rooms), firstInt(b.Rooms)) {
+		return false
+	}
+
+	if !equalOrBlank(firstInt(a.Area), firstInt(b.Area)) {
+		return false
+	}
+
+	if len(a.Signature) == 0 || len(b.Signature) == 0 {
+		return true
+	}
+
+	return similarity >= weakSimilarity
+}
+
+// Clusterize - assigns ClusterId to the new offers. The offer gets the
+//  cluster of the first duplicate found among the candidates (offers which
+//  are already in the database) or among the new offers themselves. If there
+//  are no duplicates, the offer starts its own cluster.
+func Clusterize(offers []*structs.Offer, candidates []*structs.Offer) {
+	known := make([]*structs.Offer, 0, len(candidates)+len(offers))
+	known = append(known, candidates...)
+
+	for _, offer := range offers {
+		offer.ClusterId = offer.Id
+		for _, candidate := range known {
+			if IsDuplicate(offer, candidate) {
+				offer.ClusterId = candidate.ClusterId
+				if offer.ClusterId == 0 {
+					offer.ClusterId = candidate.Id
+				}
+				break
+			}
+		}
+		known = append(known, offer)
+	}
+}
+
+// CountSamePhotos - sets SamePhotos of the new offers, the number of other
+//  clusters with at least one of their photos. The candidates get a new
+//  photo from another cluster too, they are returned if their count grows.
+//  Call it after Clusterize.
+func CountSamePhotos(offers []*structs.Offer, candidates []*structs.Offer) []*structs.Offer {
+	known := make([]*structs.Offer, 0, len(candidates)+len(offers))
+	known = append(known, candidates...)
+	known = append(known, offers...)
+
+	for _, offer := range offers {
+		offer.SamePhotos = samePhotoClusters(offer, known)
+	}
+
+	changed := make([]*structs.Offer, 0)
+	for _, candidate := range candidates {
+		count := samePhotoClusters(candidate, known)
+		if count > candidate.SamePhotos {
+			candidate.SamePhotos = count
+			changed = append(changed, candidate)
+		}
+	}
+	return changed
+}
+
+// samePhotoClusters - how many other clusters of known have a photo of the
+//  offer
+func samePhotoClusters(offer *structs.Offer, known []*structs.Offer) int {
+	hashes := offer.PhotoHashes()
+	if len(hashes) == 0 {
+		return 0
+	}
+
+	clusters := make(map[uint64]bool)
+	for _, other := range known {
+		if other.ClusterId == offer.ClusterId || clusters[other.ClusterId] {
+			continue
+		}
+		if SamePhotos(hashes, other.PhotoHashes()) > 0 {
+			clusters[other.ClusterId] = true
+		}
+	}
+	return len(clusters)
+}
+
+// RoomsNumber - the number of rooms is the first number, the sites write it
+//  differently. 0 when it is unknown.
+func RoomsNumber(rooms string) int {
+	n, _ := strconv.Atoi(firstInt(rooms))
+	return n
+}
+
+func firstInt(s string) string {
+	return intRegex.FindString(s)
+}
+
+func equalOrBlank(a, b string) bool {
+	return a == "" || b == "" || strings.EqualFold(a, b)
+}
@@ -0,0 +1,193 @@
+package dedup
+
+import (
+	"testing"
+
+	"github.com/comov/hsearch/structs"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  string
+	}{
+		{"+996 (555) 12-34-56", "+996555123456"},
+		{"996555123456", "+996555123456"},
+		{"0555 123 456", "+996555123456"},
+		{"0555-12-34-56", "+996555123456"},
+		{"555123456", "+996555123456"},
+		{"тел: 0 555 12 34 56, Азамат", "+996555123456"},
+		{"12-34-56", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePhone(tt.phone); got != tt.want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", tt.phone, got, tt.want)
+		}
+	}
+}
+
+// newOffer - the prepared offer like after parsing
+func newOffer(id uint64, site, phone string, price int, rooms, area, body string) *structs.Offer {
+	offer := &structs.Offer{
+		Id:       id,
+		Site:     site,
+		Phone:    phone,
+		Price:    price,
+		Currency: "usd",
+		Rooms:    rooms,
+		Area:     area,
+		Body:     body,
+	}
+	Prepare(offer)
+	return offer
+}
+
+func TestIsDuplicate(t *testing.T) {
+	const houseBody = `Сдается 2-комнатная квартира в центре города. Евроремонт, вся мебель и
+		бытовая техника, рядом парк, школа и остановка. Только на длительный срок,
+		без животных. Хозяин.`
+	const lalafoBody = `Сдаю 2 комнатную квартиру в центре города. Евроремонт, вся мебель и
+		бытовая техника, рядом парк, школа и остановка. Только на длительный срок,
+		без животных. Звоните!`
+
+	tests := []struct {
+		name string
+		a, b *structs.Offer
+		want bool
+	}{
+		{
+			name: "the same flat with the same text on two sites",
+			a:    newOffer(1, structs.SiteHouse, "0555 123 456", 400, "2", "60", houseBody),
+			b:    newOffer(2, structs.SiteLalafo, "+996 555 12-34-56", 400, "2 комн.", "60 м2", houseBody),
+			want: true,
+		},
+		{
+			name: "the same flat with different texts",
+			a:    newOffer(1, structs.SiteHouse, "0555 123 456", 400, "2", "60", houseBody),
+			b:    newOffer(2, structs.SiteLalafo, "+996555123456", 400, "2-комн.", "60 м2", lalafoBody),
+			want: true,
+		},
+		{
+			name: "the same flat without the text on one site",
+			a:    newOffer(1, structs.SiteHouse, "0555 123 456", 400, "2", "60", houseBody),
+			b:    newOffer(2, structs.SiteDiesel, "0555123456", 400, "", "", ""),
+			want: true,
+		},
+		{
+			name: "the agency phone with another price",
+			a:    newOffer(1, structs.SiteHouse, "0555 123 456", 400, "2", "60", houseBody),
+			b:    newOffer(2, structs.SiteLalafo, "0555 123 456", 650, "3", "90", "Сдается 3-комнатная квартира, агентство"),
+			want: false,
+		},
+		{
+			name: "the agency phone with the same price and other rooms",
+			a:    newOffer(1, structs.SiteHouse, "0555 123 456", 400, "2", "60", "Квартира в 6 мкр, мебель"),
+			b:    newOffer(2, structs.SiteLalafo, "0555 123 456", 400, "1", "35", "Квартира в Джале, без мебели"),
+			want: false,
+		},
+		{
+			name: "another phone and price",
+			a:    newOffer(1, structs.SiteHouse, "0555 123 456", 400, "2", "60", "Квартира в 6 мкр, мебель"),
+			b:    newOffer(2, structs.SiteHouse, "0700 987 654", 300, "2", "60", "Квартира в Джале, без мебели"),
+			want: false,
+		},
+		{
+			name: "the same offer is not a duplicate of itself",
+			a:    newOffer(1, structs.SiteHouse, "0555 123 456", 400, "2", "60", houseBody),
+			b:    newOffer(1, structs.SiteHouse, "0555 123 456", 400, "2", "60", houseBody),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDuplicate(tt.a, tt.b); got != tt.want {
+				t.Errorf("IsDuplicate() = %v, want %v (similarity %.2f)", got, tt.want, Similarity(tt.a.Signature, tt.b.Signature))
+			}
+		})
+	}
+}
+
+func TestClusterize(t *testing.T) {
+	candidate := newOffer(10, structs.SiteHouse, "0555 123 456", 400, "2", "60", "")
+	candidate.ClusterId = 5
+
+	offers := []*structs.Offer{
+		newOffer(20, structs.SiteLalafo, "0555123456", 400, "2", "60", ""),
+		newOffer(21, structs.SiteDiesel, "0700 987 654", 300, "1", "", ""),
+		newOffer(22, structs.SiteHouse, "+996 700 98 76 54", 300, "1", "", ""),
+		newOffer(23, structs.SiteHouse, "0777 000 111", 500, "3", "", ""),
+	}
+	Clusterize(offers, []*structs.Offer{candidate})
+
+	want := map[uint64]uint64{
+		20: 5,  // the cluster of the offer from the database
+		21: 21, // the new cluster
+		22: 21, // the duplicate of the new offer
+		23: 23,
+	}
+	for _, offer := range offers {
+		if offer.ClusterId != want[offer.Id] {
+			t.Errorf("offer %d: ClusterId = %d, want %d", offer.Id, offer.ClusterId, want[offer.Id])
+		}
+	}
+}
+
+func TestCountSamePhotos(t *testing.T) {
+	withPhotos := func(offer *structs.Offer, cluster uint64, hashes ...uint64) *structs.Offer {
+		offer.ClusterId = cluster
+		for _, hash := range hashes {
+			offer.Photos = append(offer.Photos, &structs.Image{Hash: hash})
+		}
+		return offer
+	}
+
+	const photo, recompressed, other = 0xF0F0F0F0F0F0F0F0, 0xF0F0F0F0F0F0F0F3, 0x0F0F0F0F0F0F0F0F
+	stock := withPhotos(newOffer(1, structs.SiteHouse, "0555 111 111", 400, "2", "", ""), 1, photo)
+	stock.SamePhotos = 1
+	duplicate := withPhotos(newOffer(2, structs.SiteLalafo, "0555 111 111", 400, "2", "", ""), 1, photo)
+	candidates := []*structs.Offer{stock, duplicate}
+
+	offers := []*structs.Offer{
+		withPhotos(newOffer(10, structs.SiteHouse, "0700 222 222", 300, "1", "", ""), 10, recompressed),
+		withPhotos(newOffer(11, structs.SiteLalafo, "0777 333 333", 500, "3", "", ""), 11, photo, other),
+		withPhotos(newOffer(12, structs.SiteDiesel, "0550 444 444", 500, "3", "", ""), 12, other),
+		newOffer(13, structs.SiteDiesel, "0550 555 555", 500, "3", "", ""),
+	}
+
+	changed := CountSamePhotos(offers, candidates)
+
+	want := map[uint64]int{
+		10: 2, // the cluster 1 and the offer 11
+		11: 3, // the cluster 1, the offers 10 and 12
+		12: 1,
+		13: 0,
+	}
+	for _, offer := range offers {
+		if offer.SamePhotos != want[offer.Id] {
+			t.Errorf("offer %d: SamePhotos = %d, want %d", offer.Id, offer.SamePhotos, want[offer.Id])
+		}
+	}
+
+	// both offers of the cluster 1 see the offers 10 and 11 now
+	if len(changed) != 2 || stock.SamePhotos != 2 || duplicate.SamePhotos != 2 {
+		t.Errorf("changed %d candidates with %d and %d, want 2 with 2 and 2", len(changed), stock.SamePhotos, duplicate.SamePhotos)
+	}
+}
+
+func TestRoomsNumber(t *testing.T) {
+	tests := map[string]int{
+		"2":           2,
+		"2 комн.":     2,
+		"3-комнатная": 3,
+		"студия":      0,
+		"":            0,
+	}
+	for rooms, want := range tests {
+		if got := RoomsNumber(rooms); got != want {
+			t.Errorf("RoomsNumber(%q) = %d, want %d", rooms, got, want)
+		}
+	}
+}
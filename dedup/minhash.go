@@ -0,0 +1,109 @@
+package dedup
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// shingleSize - number of words in one shingle
+	shingleSize = 3
+
+	// signatureSize - number of hash functions in the MinHash signature
+	signatureSize = 64
+)
+
+// seeds - parameters of the hash functions. Signatures are stored in the
+//  database, so the seeds must not change between releases.
+var seeds = func() [signatureSize]uint64 {
+	var s [signatureSize]uint64
+	x := uint64(0x6873656172636821) // "hsearch!"
+	for i := range s {
+		x += 0x9e3779b97f4a7c15
+		s[i] = mix(x)
+	}
+	return s
+}()
+
+// MinHash - builds the MinHash signature of the text by word shingles.
+//  Returns nil for an empty text.
+func MinHash(text string) []uint32 {
+	shingles := Shingles(text)
+	if len(shingles) == 0 {
+		return nil
+	}
+
+	signature := make([]uint32, signatureSize)
+	for i := range signature {
+		signature[i] = ^uint32(0)
+	}
+
+	for shingle := range shingles {
+		for i, seed := range seeds {
+			h := uint32(mix(shingle^seed) >> 32)
+			if h < signature[i] {
+				signature[i] = h
+			}
+		}
+	}
+	return signature
+}
+
+// Similarity - estimates the Jaccard similarity of two texts by their
+//  signatures.
+func Similarity(a, b []uint32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// Shingles - splits the text into normalized words and returns hashes of all
+//  shingleSize-word sequences.
+func Shingles(text string) map[uint64]struct{} {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	shingles := make(map[uint64]struct{})
+	if len(words) == 0 {
+		return shingles
+	}
+
+	if len(words) < shingleSize {
+		shingles[hashWords(words)] = struct{}{}
+		return shingles
+	}
+
+	for i := 0; i+shingleSize <= len(words); i++ {
+		shingles[hashWords(words[i:i+shingleSize])] = struct{}{}
+	}
+	return shingles
+}
+
+func hashWords(words []string) uint64 {
+	h := fnv.New64a()
+	for _, w := range words {
+		_, _ = h.Write([]byte(w))
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// mix - splitmix64 finalizer
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
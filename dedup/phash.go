@@ -0,0 +1,163 @@
+package dedup
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math/bits"
+)
+
+const (
+	// hashWidth, hashHeight - dHash compares neighboring pixels in a row, so
+	//  the picture is reduced to 9x8 to get 64 bits
+	hashWidth  = 9
+	hashHeight = 8
+
+	// MaxPhotoDistance - max number of different bits when two pictures are
+	//  still considered the same photo (resized, recompressed, watermarked)
+	MaxPhotoDistance = 6
+
+	// minSamePhotos - how many same photos two offers need to have
+	minSamePhotos = 2
+
+	// photoBands - the hash is split into 16-bit bands for the index. The
+	//  same photos differ in at most MaxPhotoDistance bits, it is less than
+	//  2 bits in every band, so one band differs in 0 or 1 bit.
+	photoBands = 4
+	bandBits   = 64 / photoBands
+)
+
+// HashImage - decodes the picture (jpeg, png, gif) and returns its
+//  difference hash.
+func HashImage(data []byte) (uint64, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	return DHash(img), nil
+}
+
+// DHash - perceptual difference hash. The picture is reduced to 9x8 shades
+//  of gray and each bit says whether the pixel is brighter than its right
+//  neighbor. It survives resizing and recompression, which is what sites do
+//  with the same photos.
+func DHash(img image.Image) uint64 {
+	var pixels [hashHeight][hashWidth]uint32
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return 0
+	}
+
+	for y := 0; y < hashHeight; y++ {
+		y0 := b.Min.Y + y*b.Dy()/hashHeight
+		y1 := b.Min.Y + (y+1)*b.Dy()/hashHeight
+		for x := 0; x < hashWidth; x++ {
+			x0 := b.Min.X + x*b.Dx()/hashWidth
+			x1 := b.Min.X + (x+1)*b.Dx()/hashWidth
+			pixels[y][x] = averageGray(img, x0, y0, x1, y1)
+		}
+	}
+
+	hash := uint64(0)
+	for y := 0; y < hashHeight; y++ {
+		for x := 0; x < hashWidth-1; x++ {
+			hash <<= 1
+			if pixels[y][x] > pixels[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Distance - number of different bits between two hashes
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// PhotoBands - the keys of the bands of the hash, they are stored with the
+//  image and indexed. The key is the number of the band and its bits.
+func PhotoBands(hash uint64) []int32 {
+	keys := make([]int32, 0, photoBands)
+	if hash == 0 {
+		return keys
+	}
+	for band := 0; band < photoBands; band++ {
+		keys = append(keys, bandKey(band, hash))
+	}
+	return keys
+}
+
+// NearPhotoBands - the keys to find the photos similar to the hashes by the
+//  index: every band as it is and with every one of its bits changed. A
+//  found photo still has to be checked by Distance.
+func NearPhotoBands(hashes []uint64) []int32 {
+	keys := make([]int32, 0, len(hashes)*photoBands*(bandBits+1))
+	for _, hash := range hashes {
+		for band := 0; band < photoBands; band++ {
+			keys = append(keys, bandKey(band, hash))
+			for bit := 0; bit < bandBits; bit++ {
+				keys = append(keys, bandKey(band, hash^1<<(bandShift(band)+bit)))
+			}
+		}
+	}
+	return keys
+}
+
+func bandShift(band int) int {
+	return 64 - bandBits*(band+1)
+}
+
+func bandKey(band int, hash uint64) int32 {
+	return int32(band<<bandBits) | int32(hash>>bandShift(band)&(1<<bandBits-1))
+}
+
+// SamePhotos - counts the photos of a which have a similar photo in b
+func SamePhotos(a, b []uint64) int {
+	same := 0
+	for _, ha := range a {
+		if ha == 0 {
+			continue
+		}
+		for _, hb := range b {
+			if hb != 0 && Distance(ha, hb) <= MaxPhotoDistance {
+				same++
+				break
+			}
+		}
+	}
+	return same
+}
+
+// averageGray - average brightness of the rectangle. Big pictures are not
+//  walked pixel by pixel, a grid of at most 16x16 points is enough.
+func averageGray(img image.Image, x0, y0, x1, y1 int) uint32 {
+	if x1 <= x0 {
+		x1 = x0 + 1
+	}
+	if y1 <= y0 {
+		y1 = y0 + 1
+	}
+
+	stepX := (x1-x0)/16 + 1
+	stepY := (y1-y0)/16 + 1
+
+	sum, count := uint32(0), uint32(0)
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			sum += uint32(gray(img, x, y))
+			count++
+		}
+	}
+	return sum / count
+}
+
+func gray(img image.Image, x, y int) uint8 {
+	if ycc, ok := img.(*image.YCbCr); ok {
+		return ycc.Y[ycc.YOffset(x, y)]
+	}
+	return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+}
@@ -0,0 +1,171 @@
+package dedup
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func hashFixture(t *testing.T, name string) uint64 {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := HashImage(data)
+	if err != nil {
+		t.Fatalf("HashImage(%s): %s", name, err)
+	}
+	return hash
+}
+
+func TestHashImage(t *testing.T) {
+	photo := hashFixture(t, "photo.png")
+	photoCopy := hashFixture(t, "photo_copy.jpg")
+	other := hashFixture(t, "other.jpg")
+
+	if photo == 0 {
+		t.Fatal("the hash of the photo is empty")
+	}
+
+	// the copy is smaller, recompressed with low quality and has a watermark
+	if d := Distance(photo, photoCopy); d > MaxPhotoDistance {
+		t.Errorf("the distance to the copy is %d, want at most %d", d, MaxPhotoDistance)
+	}
+
+	if d := Distance(photo, other); d <= MaxPhotoDistance {
+		t.Errorf("the distance to another photo is %d, want more than %d", d, MaxPhotoDistance)
+	}
+
+	if _, err := HashImage([]byte("not an image")); err == nil {
+		t.Error("HashImage of a broken file must fail")
+	}
+}
+
+func TestDHash(t *testing.T) {
+	gradient := func(w, h int, inverse bool) image.Image {
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				v := uint8(x * 255 / w)
+				if inverse {
+					v = 255 - v
+				}
+				img.SetGray(x, y, color.Gray{Y: v})
+			}
+		}
+		return img
+	}
+
+	// every pixel is brighter than its right neighbor
+	if got := DHash(gradient(90, 80, true)); got != ^uint64(0) {
+		t.Errorf("DHash of the inverse gradient = %x, want all bits", got)
+	}
+	if got := DHash(gradient(90, 80, false)); got != 0 {
+		t.Errorf("DHash of the gradient = %x, want 0", got)
+	}
+
+	// the size does not change the hash
+	if a, b := DHash(gradient(900, 800, true)), DHash(gradient(45, 40, true)); a != b {
+		t.Errorf("DHash depends on the size: %x != %x", a, b)
+	}
+
+	if got := DHash(image.NewGray(image.Rect(0, 0, 0, 0))); got != 0 {
+		t.Errorf("DHash of the empty image = %x, want 0", got)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0b1011, 0b1011, 0},
+		{0b1011, 0b0010, 2},
+		{0, ^uint64(0), 64},
+		{1 << 63, 1, 2},
+	}
+
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%x, %x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSamePhotos(t *testing.T) {
+	const photo = uint64(0xf0f0f0f0f0f0f0f0)
+	near := photo ^ 0b111111 // 6 bits differ
+	far := photo ^ 0b1111111 // 7 bits differ
+
+	tests := []struct {
+		name string
+		a, b []uint64
+		want int
+	}{
+		{"the same photos", []uint64{photo, 42}, []uint64{42, photo}, 2},
+		{"the recompressed photo", []uint64{photo}, []uint64{near}, 1},
+		{"too many different bits", []uint64{photo}, []uint64{far}, 0},
+		{"the photo is counted once", []uint64{photo}, []uint64{photo, near}, 1},
+		{"the empty hashes are skipped", []uint64{0, 0}, []uint64{0}, 0},
+		{"no photos", nil, []uint64{photo}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SamePhotos(tt.a, tt.b); got != tt.want {
+				t.Errorf("SamePhotos() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhotoBands(t *testing.T) {
+	if MaxPhotoDistance >= 2*photoBands {
+		t.Fatalf("MaxPhotoDistance %d needs more than %d bands", MaxPhotoDistance, photoBands)
+	}
+
+	// the same keys as the migration of the bands counts
+	got := PhotoBands(0x0123456789ABCDEF)
+	want := []int32{0x0123, 1<<16 | 0x4567, 2<<16 | 0x89AB, 3<<16 | 0xCDEF}
+	if len(got) != len(want) {
+		t.Fatalf("PhotoBands() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("PhotoBands()[%d] = %#x, want %#x", i, got[i], want[i])
+		}
+	}
+
+	if keys := PhotoBands(0); len(keys) != 0 {
+		t.Errorf("PhotoBands(0) = %v, the photo without the hash has no bands", keys)
+	}
+}
+
+func TestNearPhotoBands(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		hash := rnd.Uint64() | 1
+		similar := hash
+		for _, bit := range rnd.Perm(64)[:rnd.Intn(MaxPhotoDistance+1)] {
+			similar ^= 1 << uint(bit)
+		}
+
+		near := make(map[int32]bool)
+		for _, key := range NearPhotoBands([]uint64{hash}) {
+			near[key] = true
+		}
+
+		found := false
+		for _, key := range PhotoBands(similar) {
+			found = found || near[key]
+		}
+		if !found {
+			t.Fatalf("%016x is not found by %016x with distance %d", similar, hash, Distance(hash, similar))
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package imagestore - sites delete ads and block hotlinking, so we keep our
+//  own copies of the offer photos. Every photo is stored once by the hash of
+//  its content together with the resized versions.
+package imagestore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"strings"
+
+	"github.com/comov/hsearch/configs"
+)
+
+const (
+	// SizeOriginal - the photo as it was downloaded from the site
+	SizeOriginal = "orig"
+	// SizeLarge - the photo for Telegram and the web
+	SizeLarge = "large"
+	// SizeThumb - the preview for lists
+	SizeThumb = "thumb"
+
+	jpegQuality = 85
+
+	// MaxPixels - bigger pictures are not decoded, a small file can be
+	//  decoded to gigabytes of pixels
+	MaxPixels = 25000000
+)
+
+// ErrTooLarge - the picture has more than MaxPixels pixels
+var ErrTooLarge = errors.New("image is too large")
+
+// sizes - max side in pixels of the resized versions
+var sizes = map[string]int{
+	SizeLarge: 1280,
+	SizeThumb: 320,
+}
+
+type (
+	// Backend - the place where the files are stored
+	Backend interface {
+		Put(ctx context.Context, name string, data []byte, contentType string) error
+		Get(ctx context.Context, name string) ([]byte, error)
+		Exists(ctx context.Context, name string) (bool, error)
+		Delete(ctx context.Context, name string) error
+	}
+
+	// Store - saves photos with their resized versions to the backend
+	Store struct {
+		backend   Backend
+		publicUrl string
+	}
+)
+
+// New - creates the store from the configuration. Returns nil if storing of
+//  photos is disabled.
+func New(cnf *configs.Config) (*Store, error) {
+	var backend Backend
+	switch cnf.ImageStorage {
+	case "", "none":
+		return nil, nil
+	case "local":
+		backend = NewLocal(cnf.ImageDir)
+	case "s3":
+		backend = NewS3(cnf.S3Endpoint, cnf.S3Region, cnf.S3Bucket, cnf.S3AccessKey, cnf.S3SecretKey)
+	default:
+		return nil, fmt.Errorf("unknown image storage %s", cnf.ImageStorage)
+	}
+
+	return &Store{
+		backend:   backend,
+		publicUrl: strings.TrimSuffix(cnf.ImagePublicUrl, "/"),
+	}, nil
+}
+
+// Save - saves the photo and its resized versions and returns the key of the
+//  photo. The key is a hash of the content, so the same photo from different
+//  offers is stored only once. The picture is data decoded by Decode, the
+//  caller needs it for the hash too.
+func (s *Store) Save(ctx context.Context, data []byte, img image.Image) (string, error) {
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+
+	exists, err := s.backend.Exists(ctx, fileName(key, SizeThumb))
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return key, nil
+	}
+
+	err = s.backend.Put(ctx, fileName(key, SizeOriginal), data, http.DetectContentType(data))
+	if err != nil {
+		return "", err
+	}
+
+	// the thumb is saved last, so its existence means that all versions are
+	//  saved
+	for _, size := range []string{SizeLarge, SizeThumb} {
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, resize(img, sizes[size]), &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return "", err
+		}
+
+		err = s.backend.Put(ctx, fileName(key, size), buf.Bytes(), "image/jpeg")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return key, nil
+}
+
+// Delete - deletes the photo with all its versions. The thumb goes first, so
+//  Save does not take a half-deleted photo for a saved one.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return fmt.Errorf("invalid image key %s", key)
+	}
+
+	for _, size := range []string{SizeThumb, SizeLarge, SizeOriginal} {
+		err := s.backend.Delete(ctx, fileName(key, size))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckSize - reads only the header of the picture and returns ErrTooLarge
+//  if it has more than MaxPixels pixels
+func CheckSize(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// Decode - decodes the picture (jpeg, png, gif) if it is not too large
+func Decode(data []byte) (image.Image, error) {
+	err := CheckSize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Load - returns the photo in the needed size
+func (s *Store) Load(ctx context.Context, key, size string) ([]byte, error) {
+	if !ValidKey(key) {
+		return nil, fmt.Errorf("invalid image key %s", key)
+	}
+
+	if _, ok := sizes[size]; !ok && size != SizeOriginal {
+		return nil, fmt.Errorf("unknown image size %s", size)
+	}
+
+	return s.backend.Get(ctx, fileName(key, size))
+}
+
+// Url - returns the public url of the photo or an empty string if the photos
+//  are not available from the outside
+func (s *Store) Url(key, size string) string {
+	if s == nil || s.publicUrl == "" || key == "" {
+		return ""
+	}
+	return s.publicUrl + "/" + fileName(key, size)
+}
+
+// ValidKey - the key is a sha256 in hex, anything else can be an attempt to
+//  read another file
+func ValidKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
+
+func fileName(key, size string) string {
+	return key + "/" + size + ".jpg"
+}
@@ -0,0 +1,156 @@
+package imagestore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func encodePng(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{strings.Repeat("ab", 32), true},
+		{strings.Repeat("AB", 32), true},
+		{strings.Repeat("ab", 31), false},
+		{strings.Repeat("ab", 33), false},
+		{strings.Repeat("zz", 32), false},
+		{"../../" + strings.Repeat("a", 58), false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidKey(tt.key); got != tt.want {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name          string
+		w, h, maxSide int
+		wantW, wantH  int
+	}{
+		{"the small picture is not changed", 200, 100, 320, 200, 100},
+		{"the landscape picture", 1000, 500, 320, 320, 160},
+		{"the portrait picture", 500, 1000, 320, 160, 320},
+		{"the square picture", 640, 640, 320, 320, 320},
+		{"the thin line keeps one pixel", 10000, 2, 320, 320, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resize(image.NewRGBA(image.Rect(0, 0, tt.w, tt.h)), tt.maxSide).Bounds()
+			if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+				t.Errorf("resize() = %dx%d, want %dx%d", got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestResizeAverage(t *testing.T) {
+	// the black and white columns become gray
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			c := color.RGBA{A: 255}
+			if x%2 == 1 {
+				c = color.RGBA{R: 200, G: 200, B: 200, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	got := resize(img, 2).(*image.RGBA)
+	want := color.RGBA{R: 100, G: 100, B: 100, A: 255}
+	if c := got.RGBAAt(0, 0); c != want {
+		t.Errorf("the pixel = %v, want %v", c, want)
+	}
+}
+
+func TestCheckSize(t *testing.T) {
+	small := encodePng(t, image.NewGray(image.Rect(0, 0, 100, 100)))
+	if err := CheckSize(small); err != nil {
+		t.Errorf("CheckSize(100x100) = %v", err)
+	}
+
+	// the header of the png says 10000x10000, the pixels are never decoded
+	huge := encodePng(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	copy(huge[16:24], []byte{0, 0, 0x27, 0x10, 0, 0, 0x27, 0x10})
+	binary.BigEndian.PutUint32(huge[29:33], crc32.ChecksumIEEE(huge[12:29]))
+	if err := CheckSize(huge); err != ErrTooLarge {
+		t.Errorf("CheckSize(10000x10000) = %v, want ErrTooLarge", err)
+	}
+
+	if err := CheckSize([]byte("not an image")); err == nil {
+		t.Error("CheckSize of a broken file must fail")
+	}
+
+	if _, err := Decode(huge); err != ErrTooLarge {
+		t.Errorf("Decode(10000x10000) = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imagestore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	store := &Store{backend: NewLocal(dir)}
+	data := encodePng(t, image.NewGray(image.Rect(0, 0, 2000, 1000)))
+
+	img, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.Save(ctx, data, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thumb, err := store.Load(ctx, key, SizeThumb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != sizes[SizeThumb] {
+		t.Errorf("the thumb width = %d, want %d", config.Width, sizes[SizeThumb])
+	}
+
+	if _, err := store.Load(ctx, "../"+key, SizeThumb); err == nil {
+		t.Error("Load with a wrong key must fail")
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, key)); !os.IsNotExist(err) {
+		t.Errorf("the directory of the photo is not deleted: %v", err)
+	}
+}
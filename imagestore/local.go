@@ -0,0 +1,75 @@
+package imagestore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Local - stores files in a directory on the disk
+type Local struct {
+	dir string
+}
+
+// NewLocal - creates the local backend in the directory
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+// Put - writes the file through a temporary one, so a reader never gets
+//  a half-written photo
+func (l *Local) Put(_ context.Context, name string, data []byte, _ string) error {
+	path := filepath.Join(l.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get - reads the file
+func (l *Local) Get(_ context.Context, name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(l.dir, filepath.FromSlash(name)))
+}
+
+// Exists - checks that the file was saved
+func (l *Local) Exists(_ context.Context, name string) (bool, error) {
+	_, err := os.Stat(filepath.Join(l.dir, filepath.FromSlash(name)))
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// Delete - removes the file and the directory of the photo when it is empty
+func (l *Local) Delete(_ context.Context, name string) error {
+	path := filepath.Join(l.dir, filepath.FromSlash(name))
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// fails while other versions of the photo are in the directory
+	_ = os.Remove(filepath.Dir(path))
+	return nil
+}
@@ -0,0 +1,23 @@
+alter table offer
+    add column cluster_id integer     default 0  not null,
+    add column phone_norm varchar(20) default '',
+    add column signature  bytea;
+
+update offer
+set cluster_id = id
+where cluster_id = 0;
+
+create index offer_cluster_id_index
+    on offer (cluster_id);
+
+create index offer_phone_norm_index
+    on offer (phone_norm);
+
+---- create above / drop below ----
+drop index if exists offer_phone_norm_index;
+drop index if exists offer_cluster_id_index;
+
+alter table offer
+    drop column if exists cluster_id,
+    drop column if exists phone_norm,
+    drop column if exists signature;
@@ -0,0 +1,11 @@
+alter table image
+    add column hash bigint default 0 not null;
+
+create index image_hash_index
+    on image (hash);
+
+---- create above / drop below ----
+drop index if exists image_hash_index;
+
+alter table image
+    drop column if exists hash;
@@ -0,0 +1,6 @@
+alter table image
+    add column key varchar(64) default '' not null;
+
+---- create above / drop below ----
+alter table image
+    drop column if exists key;
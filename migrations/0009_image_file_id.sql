@@ -0,0 +1,6 @@
+alter table image
+    add column file_id varchar(255) default '' not null;
+
+---- create above / drop below ----
+alter table image
+    drop column if exists file_id;
@@ -0,0 +1,14 @@
+create table chat_state
+(
+    chat     bigint      not null
+        constraint chat_state_pk primary key,
+    state    varchar(50) not null,
+    data     text      default '{}',
+    retries  integer   default 0,
+    deadline integer   default 0,
+    messages integer[] default '{}',
+    created  integer     not null
+);
+
+---- create above / drop below ----
+drop table if exists chat_state;
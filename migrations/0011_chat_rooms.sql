@@ -0,0 +1,6 @@
+alter table chat
+    add column rooms varchar(100) default '0:0' not null;
+
+---- create above / drop below ----
+alter table chat
+    drop column if exists rooms;
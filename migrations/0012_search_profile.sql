@@ -0,0 +1,22 @@
+create table search_profile
+(
+    id      serial      not null
+        constraint search_profile_pk primary key,
+    chat    bigint      not null,
+    name    varchar(50) not null,
+    enable  boolean      default true,
+    diesel  boolean      default true,
+    lalafo  boolean      default true,
+    house   boolean      default true,
+    photo   boolean      default false,
+    usd     varchar(100) default '0:0' not null,
+    kgs     varchar(100) default '0:0' not null,
+    rooms   varchar(100) default '0:0' not null,
+    created integer     not null
+);
+
+create unique index search_profile_chat_name_uindex
+    on search_profile (chat, name);
+
+---- create above / drop below ----
+drop table if exists search_profile;
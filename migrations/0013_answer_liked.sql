@@ -0,0 +1,11 @@
+alter table answer
+    add column liked boolean default false not null;
+
+create index answer_chat_liked_index
+    on answer (chat) where liked;
+
+---- create above / drop below ----
+drop index if exists answer_chat_liked_index;
+
+alter table answer
+    drop column if exists liked;
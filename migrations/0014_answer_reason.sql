@@ -0,0 +1,6 @@
+alter table answer
+    add column reason varchar(20) default '' not null;
+
+---- create above / drop below ----
+alter table answer
+    drop column if exists reason;
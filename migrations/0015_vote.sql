@@ -0,0 +1,23 @@
+create table vote
+(
+    id       serial  not null
+        constraint vote_pk primary key,
+    created  integer not null,
+    chat     bigint  not null,
+    offer_id integer not null,
+    user_id  bigint  not null,
+    username varchar(100) default '',
+    value    smallint not null
+);
+
+create unique index vote_chat_offer_user_uindex
+    on vote (chat, offer_id, user_id);
+
+alter table chat
+    add column vote_rule varchar(10) default 'any' not null;
+
+---- create above / drop below ----
+alter table chat
+    drop column if exists vote_rule;
+
+drop table if exists vote;
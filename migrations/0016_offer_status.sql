@@ -0,0 +1,16 @@
+create table offer_status
+(
+    id       serial  not null
+        constraint offer_status_pk primary key,
+    created  integer not null,
+    updated  integer not null,
+    chat     bigint  not null,
+    offer_id integer not null,
+    status   varchar(20) not null
+);
+
+create unique index offer_status_chat_offer_uindex
+    on offer_status (chat, offer_id);
+
+---- create above / drop below ----
+drop table if exists offer_status;
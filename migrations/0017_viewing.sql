@@ -0,0 +1,22 @@
+create table viewing
+(
+    id       serial  not null
+        constraint viewing_pk primary key,
+    created  integer not null,
+    chat     bigint  not null,
+    offer_id integer not null,
+    at       integer not null,
+    reminded boolean default false not null
+);
+
+create index viewing_chat_at_index
+    on viewing (chat, at);
+
+alter table chat
+    add column timezone varchar(50) default 'Asia/Bishkek' not null;
+
+---- create above / drop below ----
+alter table chat
+    drop column if exists timezone;
+
+drop table if exists viewing;
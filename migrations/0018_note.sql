@@ -0,0 +1,17 @@
+create table note
+(
+    id       serial  not null
+        constraint note_pk primary key,
+    created  integer not null,
+    chat     bigint  not null,
+    offer_id integer not null,
+    user_id  bigint  not null,
+    username varchar(100) default '',
+    body     text    not null
+);
+
+create index note_chat_offer_index
+    on note (chat, offer_id);
+
+---- create above / drop below ----
+drop table if exists note;
@@ -0,0 +1,15 @@
+create table snooze
+(
+    id       serial  not null
+        constraint snooze_pk primary key,
+    created  integer not null,
+    chat     bigint  not null,
+    offer_id integer not null,
+    until    integer not null
+);
+
+create unique index snooze_chat_offer_uindex
+    on snooze (chat, offer_id);
+
+---- create above / drop below ----
+drop table if exists snooze;
@@ -0,0 +1,24 @@
+create table report
+(
+    id       serial  not null
+        constraint report_pk primary key,
+    created  integer not null,
+    chat     bigint  not null,
+    user_id  bigint  not null,
+    username varchar(100) default '',
+    offer_id integer not null,
+    reason   varchar(20) not null,
+    status   varchar(20) default 'new' not null
+);
+
+create unique index report_chat_offer_uindex
+    on report (chat, offer_id);
+
+alter table offer
+    add column hidden boolean default false not null;
+
+---- create above / drop below ----
+alter table offer
+    drop column if exists hidden;
+
+drop table if exists report;
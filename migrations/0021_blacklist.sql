@@ -0,0 +1,15 @@
+create table blacklist
+(
+    id      serial  not null
+        constraint blacklist_pk primary key,
+    created integer not null,
+    chat    bigint  not null,
+    phone   varchar(20) not null,
+    reason  varchar(100) default ''
+);
+
+create unique index blacklist_chat_phone_uindex
+    on blacklist (chat, phone);
+
+---- create above / drop below ----
+drop table if exists blacklist;
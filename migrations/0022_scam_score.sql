@@ -0,0 +1,24 @@
+alter table offer
+    add column scam_score smallint default 0 not null;
+
+alter table offer
+    add column scam_reasons varchar(20)[] default '{}' not null;
+
+alter table chat
+    add column hide_suspicious boolean default false not null;
+
+alter table search_profile
+    add column hide_suspicious boolean default false not null;
+
+---- create above / drop below ----
+alter table search_profile
+    drop column if exists hide_suspicious;
+
+alter table chat
+    drop column if exists hide_suspicious;
+
+alter table offer
+    drop column if exists scam_reasons;
+
+alter table offer
+    drop column if exists scam_score;
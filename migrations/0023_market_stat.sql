@@ -0,0 +1,20 @@
+create table market_stat
+(
+    id       serial  not null
+        constraint market_stat_pk primary key,
+    updated  integer not null,
+    city     varchar(100) not null,
+    district varchar(100) not null,
+    rooms    integer not null,
+    currency varchar(10) not null,
+    p25      integer not null,
+    median   integer not null,
+    p75      integer not null,
+    samples  integer not null
+);
+
+create unique index market_stat_uindex
+    on market_stat (city, district, rooms, currency);
+
+---- create above / drop below ----
+drop table if exists market_stat;
@@ -0,0 +1,31 @@
+create table offer_archive
+(
+    like offer
+);
+
+create unique index offer_archive_id_uindex
+    on offer_archive (id);
+
+create index offer_archive_created_index
+    on offer_archive (created);
+
+create table image_archive
+(
+    like image
+);
+
+create index image_archive_offer_id_index
+    on image_archive (offer_id);
+
+create table answer_archive
+(
+    like answer
+);
+
+create index answer_archive_offer_id_index
+    on answer_archive (offer_id);
+
+---- create above / drop below ----
+drop table if exists answer_archive;
+drop table if exists image_archive;
+drop table if exists offer_archive;
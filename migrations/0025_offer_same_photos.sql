@@ -0,0 +1,27 @@
+alter table offer
+    add column same_photos integer default 0 not null;
+
+alter table offer_archive
+    add column same_photos integer default 0 not null;
+
+-- the fresh offers get the count once, the new ones get it when they are
+-- written
+update offer of
+set same_photos = (
+    select count(distinct so.cluster_id)
+    from image im
+             join image si on (si.offer_id != im.offer_id and si.hash != 0 and
+                               length(replace((im.hash # si.hash)::bit(64)::text, '0', '')) <= 6)
+             join offer so on (so.id = si.offer_id)
+    where im.offer_id = of.id
+      and im.hash != 0
+      and so.cluster_id != of.cluster_id
+)
+where exists(select 1 from image im where im.offer_id = of.id and im.hash != 0);
+
+---- create above / drop below ----
+alter table offer_archive
+    drop column if exists same_photos;
+
+alter table offer
+    drop column if exists same_photos;
@@ -0,0 +1,27 @@
+-- the keys of the 16-bit bands of the photo hash, see dedup.PhotoBands
+alter table image
+    add column bands integer[] default '{}' not null;
+
+alter table image_archive
+    add column bands integer[] default '{}' not null;
+
+update image
+set bands = array [
+    ((hash >> 48) & 65535)::integer,
+    (65536 | ((hash >> 32) & 65535))::integer,
+    (131072 | ((hash >> 16) & 65535))::integer,
+    (196608 | (hash & 65535))::integer
+    ]
+where hash != 0;
+
+create index image_bands_index
+    on image using gin (bands);
+
+---- create above / drop below ----
+drop index if exists image_bands_index;
+
+alter table image_archive
+    drop column if exists bands;
+
+alter table image
+    drop column if exists bands;
@@ -0,0 +1,22 @@
+-- the number of rooms is the first number of room_numbers, it is the key of
+-- the market statistics and of the rooms filter, see dedup.RoomsNumber
+alter table offer
+    add column rooms integer default 0 not null;
+
+alter table offer_archive
+    add column rooms integer default 0 not null;
+
+update offer
+set rooms = substring(room_numbers from '[0-9]{1,9}')::integer
+where room_numbers ~ '[0-9]';
+
+update offer_archive
+set rooms = substring(room_numbers from '[0-9]{1,9}')::integer
+where room_numbers ~ '[0-9]';
+
+---- create above / drop below ----
+alter table offer_archive
+    drop column if exists rooms;
+
+alter table offer
+    drop column if exists rooms;
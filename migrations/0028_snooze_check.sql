@@ -0,0 +1,14 @@
+-- the liveness of the snoozed offer is checked by the snooze checker before
+-- the matcher sends it again, see background/snoozes.go
+alter table snooze
+    add column checked varchar(10) default '' not null;
+
+alter table snooze
+    add column attempts integer default 0 not null;
+
+---- create above / drop below ----
+alter table snooze
+    drop column if exists attempts;
+
+alter table snooze
+    drop column if exists checked;
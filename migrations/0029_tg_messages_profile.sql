@@ -0,0 +1,8 @@
+-- the name of the search profile the card was sent for, the card keeps the
+-- label when it is edited
+alter table tg_messages
+    add column profile varchar(50) default '' not null;
+
+---- create above / drop below ----
+alter table tg_messages
+    drop column if exists profile;
@@ -0,0 +1,31 @@
+-- the chat has one answer about the offer. The duplicates which the old
+-- like and dislike could leave are merged into the first row
+update answer a
+set liked   = d.liked and not d.dislike,
+    dislike = d.dislike,
+    reason  = d.reason,
+    created = d.created
+from (
+    select min(id)                           as id,
+           bool_or(liked)                    as liked,
+           coalesce(bool_or(dislike), false) as dislike,
+           max(reason)                       as reason,
+           max(created)                      as created
+    from answer
+    group by chat, offer_id
+    having count(*) > 1
+) d
+where a.id = d.id;
+
+delete
+from answer a
+    using answer b
+where a.chat = b.chat
+  and a.offer_id = b.offer_id
+  and a.id > b.id;
+
+create unique index answer_chat_offer_id_uindex
+    on answer (chat, offer_id);
+
+---- create above / drop below ----
+drop index if exists answer_chat_offer_id_uindex;
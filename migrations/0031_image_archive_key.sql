@@ -0,0 +1,17 @@
+-- the files of the photos are deleted by the keys of the archived images
+create index image_archive_key_index
+    on image_archive (key);
+
+-- the image is archived once, like the offer
+delete
+from image_archive a
+    using image_archive b
+where a.id = b.id
+  and a.ctid > b.ctid;
+
+create unique index image_archive_id_uindex
+    on image_archive (id);
+
+---- create above / drop below ----
+drop index if exists image_archive_id_uindex;
+drop index if exists image_archive_key_index;
@@ -14,18 +14,20 @@ import (
 )
 
 type Diesel struct {
-	Site         string
-	Host         string
-	Target       string
-	MainSelector string
+	Site            string
+	Host            string
+	Target          string
+	MainSelector    string
+	RemovedSelector string
 }
 
 func DieselSite() *Diesel {
 	return &Diesel{
-		Site:         structs.SiteDiesel,
-		Host:         "http://diesel.elcat.kg",
-		Target:       "http://diesel.elcat.kg/index.php?showforum=305",
-		MainSelector: ".topic_title",
+		Site:            structs.SiteDiesel,
+		Host:            "http://diesel.elcat.kg",
+		Target:          "http://diesel.elcat.kg/index.php?showforum=305",
+		MainSelector:    ".topic_title",
+		RemovedSelector: ".message.error",
 	}
 }
 
@@ -105,6 +107,11 @@ func (s *Diesel) ParseNewOffer(href string, exId uint64, doc *goquery.Document)
 	}
 }
 
+// IsRemoved - the forum shows the error instead of the removed topic
+func (s *Diesel) IsRemoved(doc *goquery.Document) bool {
+	return pageSays(doc.Find(s.RemovedSelector), "тема не найдена", "запрошенная тема не существует", "тема была удалена")
+}
+
 // parseTitle - find topic title
 func (s *Diesel) parseTitle(doc *goquery.Document) string {
 	return doc.Find(".ipsType_pagetitle").Text()
@@ -13,18 +13,20 @@ import (
 )
 
 type House struct {
-	Site         string
-	Host         string
-	Target       string
-	MainSelector string
+	Site            string
+	Host            string
+	Target          string
+	MainSelector    string
+	RemovedSelector string
 }
 
 func HouseSite() *House {
 	return &House{
-		Site:         structs.SiteHouse,
-		Host:         "https://www.house.kg",
-		Target:       "https://www.house.kg/snyat-kvartiru?region=1&town=2&rental_term=3&sort_by=upped_at+desc&page=%d",
-		MainSelector: "p.title > a",
+		Site:            structs.SiteHouse,
+		Host:            "https://www.house.kg",
+		Target:          "https://www.house.kg/snyat-kvartiru?region=1&town=2&rental_term=3&sort_by=upped_at+desc&page=%d",
+		MainSelector:    "p.title > a",
+		RemovedSelector: ".alert",
 	}
 }
 
@@ -116,6 +118,11 @@ func (s *House) ParseNewOffer(href string, exId uint64, doc *goquery.Document) *
 	}
 }
 
+// IsRemoved - house.kg shows the message instead of the removed offer
+func (s *House) IsRemoved(doc *goquery.Document) bool {
+	return pageSays(doc.Find(s.RemovedSelector), "объявление удалено", "объявление не активно", "объявление снято с публикации")
+}
+
 // parseTitle - find topic title
 func (s *House) parseTitle(doc *goquery.Document) string {
 	return strings.TrimSpace(doc.Find(".left > h1").Text())
@@ -14,10 +14,11 @@ import (
 )
 
 type Lalafo struct {
-	Site         string
-	Host         string
-	Target       string
-	MainSelector string
+	Site            string
+	Host            string
+	Target          string
+	MainSelector    string
+	RemovedSelector string
 }
 
 type MainPageResponse struct {
@@ -37,10 +38,11 @@ type MainPageResponse struct {
 
 func LalafoSite() *Lalafo {
 	return &Lalafo{
-		Site:         structs.SiteLalafo,
-		Host:         "https://lalafo.kg",
-		Target:       "https://lalafo.kg/kyrgyzstan/kvartiry/arenda-kvartir/dolgosrochnaya-arenda-kvartir",
-		MainSelector: "#__NEXT_DATA__",
+		Site:            structs.SiteLalafo,
+		Host:            "https://lalafo.kg",
+		Target:          "https://lalafo.kg/kyrgyzstan/kvartiry/arenda-kvartir/dolgosrochnaya-arenda-kvartir",
+		MainSelector:    "#__NEXT_DATA__",
+		RemovedSelector: ".ad-detail-status",
 	}
 }
 
@@ -235,6 +237,11 @@ func (o *LalafoOffer) imagesAsString() []string {
 	return images
 }
 
+// IsRemoved - lalafo.kg shows the message instead of the removed offer
+func (s *Lalafo) IsRemoved(doc *goquery.Document) bool {
+	return pageSays(doc.Find(s.RemovedSelector), "объявление удалено", "объявление было удалено", "объявление неактивно", "объявление не активно")
+}
+
 func (s *Lalafo) findAndParseJsonOffer(doc *goquery.Document) LalafoOffer {
 	foundJson := JsonStruct{}
 
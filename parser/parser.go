@@ -3,10 +3,13 @@ package parser
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,6 +28,7 @@ type (
 		GetOffersMap(doc *goquery.Document) OffersMap
 		IdFromHref(href string) (uint64, error)
 		ParseNewOffer(href string, exId uint64, doc *goquery.Document) *structs.Offer
+		IsRemoved(doc *goquery.Document) bool
 	}
 )
 
@@ -34,6 +38,15 @@ const negativeTheme = 2477961
 
 type OffersMap = map[uint64]string
 
+// MaxImageSize - Telegram does not accept photos larger than 10MB
+const MaxImageSize = 10 << 20
+
+// requestTimeout - the sites sometimes hang, the request must not block the
+//  grabber or the matcher forever
+const requestTimeout = 30 * time.Second
+
+var client = &http.Client{Timeout: requestTimeout}
+
 var (
 	intRegex  = regexp.MustCompile(`\d+`)
 	textRegex = regexp.MustCompile(`[a-zA-Zа-яА-Я]+`)
@@ -122,7 +135,7 @@ func LoadOffersDetail(offersList map[uint64]string, site Site) []*structs.Offer
 // GetDocumentByUrl - получает страницу по http, читает и возвращет объект
 // goquery.Document для парсинга
 func GetDocumentByUrl(url string) (*goquery.Document, error) {
-	res, err := http.Get(url)
+	res, err := client.Get(url)
 	if err != nil {
 		log.Println("[GetDocumentByUrl.Get] error:", err)
 		return nil, err
@@ -142,6 +155,85 @@ func GetDocumentByUrl(url string) (*goquery.Document, error) {
 	return goquery.NewDocumentFromReader(res.Body)
 }
 
+// IsOfferAlive - checks that the offer is still on the site. The answer
+//  "not found" or the page of the removed offer means that the offer is
+//  removed, other errors can be temporary. Some sites answer 200 for the
+//  removed offers, so the page is checked by the site.
+func IsOfferAlive(site Site, url string) (bool, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		err := res.Body.Close()
+		if err != nil {
+			log.Println("[IsOfferAlive.defer.Close] error:", err)
+		}
+	}()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		if site == nil {
+			return true, nil
+		}
+
+		doc, err := goquery.NewDocumentFromReader(res.Body)
+		if err != nil {
+			return false, err
+		}
+		return !site.IsRemoved(doc), nil
+	case http.StatusNotFound, http.StatusGone:
+		return false, nil
+	}
+	return false, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+}
+
+// GetImageByUrl - загружает картинку по http. Слишком большие картинки не
+// загружаем, Telegram их все равно не примет
+func GetImageByUrl(url string) ([]byte, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		log.Println("[GetImageByUrl.Get] error:", err)
+		return nil, err
+	}
+
+	defer func() {
+		err := res.Body.Close()
+		if err != nil {
+			log.Println("[GetImageByUrl.defer.Close] error:", err)
+		}
+	}()
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, MaxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > MaxImageSize {
+		return nil, fmt.Errorf("image %s is larger than %d bytes", url, MaxImageSize)
+	}
+
+	return data, nil
+}
+
+// pageSays - the status element of the page contains one of the phrases,
+//  the case is ignored. The whole page is not checked, the description may
+//  quote the phrase.
+func pageSays(status *goquery.Selection, phrases ...string) bool {
+	text := strings.ToLower(status.Text())
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
 func DefaultParser(site Site, doc *goquery.Document) OffersMap {
 	var mapResponse = make(OffersMap, 0)
 	doc.Find(site.Selector()).Each(func(i int, s *goquery.Selection) {
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestIsOfferAlive(t *testing.T) {
+	pages := map[string]struct {
+		status int
+		body   string
+	}{
+		"/offer":   {http.StatusOK, `<html><body><div class="left"><h1>Сдаю 2-комн.</h1></div></body></html>`},
+		"/quoted":  {http.StatusOK, `<html><body><p>Если объявление удалено, квартира сдана</p></body></html>`},
+		"/removed": {http.StatusOK, `<html><body><div class="alert">Объявление удалено</div></body></html>`},
+		"/ad":      {http.StatusOK, `<html><body><div class="ad-detail-status">Объявление удалено</div></body></html>`},
+		"/topic":   {http.StatusOK, `<html><body><div class="message error">Запрошенная тема не существует</div></body></html>`},
+		"/gone":    {http.StatusGone, ""},
+		"/broken":  {http.StatusBadGateway, ""},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(page.status)
+		_, _ = fmt.Fprint(w, page.body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		site  Site
+		path  string
+		alive bool
+		err   bool
+	}{
+		{"offer", HouseSite(), "/offer", true, false},
+		{"removed offer with 200", HouseSite(), "/removed", false, false},
+		{"phrase in the description", HouseSite(), "/quoted", true, false},
+		{"removed offer on lalafo", LalafoSite(), "/ad", false, false},
+		{"removed topic", DieselSite(), "/topic", false, false},
+		{"other site message", HouseSite(), "/topic", true, false},
+		{"unknown site", nil, "/removed", true, false},
+		{"not found", HouseSite(), "/unknown", false, false},
+		{"gone", HouseSite(), "/gone", false, false},
+		{"temporary error", HouseSite(), "/broken", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alive, err := IsOfferAlive(tt.site, server.URL+tt.path)
+			if (err != nil) != tt.err {
+				t.Fatalf("IsOfferAlive() error = %v, want error %v", err, tt.err)
+			}
+			if alive != tt.alive {
+				t.Errorf("IsOfferAlive() = %v, want %v", alive, tt.alive)
+			}
+		})
+	}
+}
+
+func TestIsRemoved(t *testing.T) {
+	tests := []struct {
+		name    string
+		site    Site
+		page    string
+		removed bool
+	}{
+		{"house active", HouseSite(), "house_active.html", false},
+		{"house removed", HouseSite(), "house_removed.html", true},
+		{"lalafo active", LalafoSite(), "lalafo_active.html", false},
+		{"lalafo removed", LalafoSite(), "lalafo_removed.html", true},
+		{"diesel active", DieselSite(), "diesel_active.html", false},
+		{"diesel removed", DieselSite(), "diesel_removed.html", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			doc, err := goquery.NewDocumentFromReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed := tt.site.IsRemoved(doc); removed != tt.removed {
+				t.Errorf("IsRemoved() = %v, want %v", removed, tt.removed)
+			}
+		})
+	}
+}
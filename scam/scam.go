@@ -0,0 +1,149 @@
+// Package scam - scores how much the new offer looks like a fraud: "pay in
+//  advance and I will send the keys" flats are too cheap, have no photos or
+//  photos stolen from other offers, and their phones get reported.
+package scam
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/comov/hsearch/dedup"
+	"github.com/comov/hsearch/structs"
+)
+
+// the reasons of the score, the bot shows them to the user
+const (
+	ReasonLowPrice   = "low_price"
+	ReasonPrepayment = "prepayment"
+	ReasonNoPhotos   = "no_photos"
+	ReasonReported   = "reported_phone"
+	ReasonStockPhoto = "stock_photo"
+)
+
+const (
+	// Suspicious - the offer with such score gets the warning and is hidden
+	//  by the "hide suspicious" filter
+	Suspicious = 50
+
+	// lowPriceRatio - the price is suspicious if it is lower than this part
+	//  of the median price of the same flats
+	lowPriceRatio = 0.5
+
+	// minSamples - the median of fewer offers is not trusted
+	minSamples = 5
+)
+
+// weights - how much every reason adds to the score
+var weights = map[string]int{
+	ReasonLowPrice:   35,
+	ReasonPrepayment: 40,
+	ReasonNoPhotos:   15,
+	ReasonReported:   40,
+	ReasonStockPhoto: 25,
+}
+
+// prepaymentWords - people asking to pay before the viewing
+var prepaymentWords = []string{
+	"предоплат",
+	"аванс",
+	"оплата вперед",
+	"оплата вперёд",
+	"оплатить вперед",
+	"оплатить вперёд",
+	"перевод на карту",
+	"перевести на карту",
+	"переведите",
+	"скиньте на карту",
+	"золотая корона",
+	"western union",
+	"вестерн юнион",
+	"до просмотра",
+}
+
+type (
+	// Median - the median price of the offers with the same city, district,
+	//  rooms and currency from the market statistics, and how many offers it
+	//  is counted by
+	Median struct {
+		Price   int
+		Samples int
+	}
+
+	// Signals - what we know about the offer from the database
+	Signals struct {
+		// Medians - by MedianKey
+		Medians map[string]Median
+		// ReportedPhones - normalized phones of the reported or blacklisted
+		//  offers
+		ReportedPhones map[string]bool
+		// Known - fresh offers from the database, to find the same photos in
+		//  other clusters
+		Known []*structs.Offer
+	}
+)
+
+// MedianKey - the key of the median price of the same flats, the rooms are
+//  counted like in the market statistics
+func MedianKey(city, district string, rooms int, currency string) string {
+	return strings.ToLower(fmt.Sprintf("%s|%s|%d|%s", city, district, rooms, currency))
+}
+
+// Score - from 0 to 100, how suspicious the offer is, and the reasons
+func Score(offer *structs.Offer, signals *Signals) (int, []string) {
+	reasons := make([]string, 0)
+
+	key := MedianKey(offer.City, offer.District, dedup.RoomsNumber(offer.Rooms), offer.Currency)
+	median, ok := signals.Medians[key]
+	if ok && median.Samples >= minSamples && offer.Price > 0 &&
+		float64(offer.Price) < float64(median.Price)*lowPriceRatio {
+		reasons = append(reasons, ReasonLowPrice)
+	}
+
+	body := strings.ToLower(offer.Topic + " " + offer.Body)
+	for _, word := range prepaymentWords {
+		if strings.Contains(body, word) {
+			reasons = append(reasons, ReasonPrepayment)
+			break
+		}
+	}
+
+	if offer.Images == 0 && len(offer.ImagesList) == 0 {
+		reasons = append(reasons, ReasonNoPhotos)
+	}
+
+	if offer.PhoneNorm != "" && signals.ReportedPhones[offer.PhoneNorm] {
+		reasons = append(reasons, ReasonReported)
+	}
+
+	if stockPhotos(offer, signals.Known) {
+		reasons = append(reasons, ReasonStockPhoto)
+	}
+
+	score := 0
+	for _, reason := range reasons {
+		score += weights[reason]
+	}
+	if score > 100 {
+		score = 100
+	}
+	return score, reasons
+}
+
+// stockPhotos - the photos of the offer are used by another flat, the offer
+//  is not a duplicate of it, otherwise they would be in one cluster
+func stockPhotos(offer *structs.Offer, known []*structs.Offer) bool {
+	hashes := offer.PhotoHashes()
+	if len(hashes) == 0 {
+		return false
+	}
+
+	for _, other := range known {
+		if other.ClusterId == offer.ClusterId || other.Id == offer.Id {
+			continue
+		}
+		if dedup.SamePhotos(hashes, other.PhotoHashes()) > 0 {
+			return true
+		}
+	}
+	return false
+}
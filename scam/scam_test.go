@@ -0,0 +1,49 @@
+package scam
+
+import (
+	"testing"
+
+	"github.com/comov/hsearch/structs"
+)
+
+func TestScoreLowPrice(t *testing.T) {
+	signals := &Signals{
+		Medians: map[string]Median{
+			MedianKey("Бишкек", "Джал", 2, "usd"):  {Price: 400, Samples: 12},
+			MedianKey("Бишкек", "Центр", 2, "usd"): {Price: 500, Samples: 2},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		district string
+		rooms    string
+		price    int
+		want     bool
+	}{
+		{"cheap", "Джал", "2 комн.", 150, true},
+		{"cheap with other rooms format", "джал", "2-комнатная", 150, true},
+		{"normal price", "Джал", "2", 350, false},
+		{"other rooms", "Джал", "3 комн.", 150, false},
+		{"too few samples", "Центр", "2", 150, false},
+		{"unknown rooms", "Джал", "", 150, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offer := &structs.Offer{
+				City:     "Бишкек",
+				District: tt.district,
+				Rooms:    tt.rooms,
+				Price:    tt.price,
+				Currency: "usd",
+				Images:   1,
+			}
+			_, reasons := Score(offer, signals)
+			got := len(reasons) != 0 && reasons[0] == ReasonLowPrice
+			if got != tt.want {
+				t.Errorf("low price = %v, want %v (reasons %v)", got, tt.want, reasons)
+			}
+		})
+	}
+}
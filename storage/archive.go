@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+)
+
+// the columns which are moved to the archive. A new column of offer, image
+//  or answer must be added to the archive table and here, otherwise it is
+//  lost in the archive.
+const (
+	offerArchiveColumns = `id, created, url, topic, full_price, phone, room_numbers, body, images,
+		price, currency, area, city, room_type, site, floor, district, cluster_id, phone_norm,
+		signature, hidden, scam_score, scam_reasons, same_photos, rooms`
+	imageArchiveColumns  = `id, offer_id, path, created, hash, key, file_id, bands`
+	answerArchiveColumns = `id, created, chat, offer_id, dislike, liked, reason`
+)
+
+// withArchive - the offers and the archived offers, for the queries over
+//  months
+const withArchive = `SELECT ` + offerArchiveColumns + ` FROM offer
+	UNION ALL
+	SELECT ` + offerArchiveColumns + ` FROM offer_archive`
+
+// CleanExpiredArchive - deletes the archived offers, images and answers
+//  which are older than the archive retention
+func (c *Connector) CleanExpiredArchive(ctx context.Context, expireDate int64) (int64, error) {
+	var count int64
+	for _, query := range []string{
+		`DELETE FROM offer_archive WHERE created < $1;`,
+		`DELETE FROM image_archive WHERE created < $1;`,
+		`DELETE FROM answer_archive WHERE created < $1;`,
+	} {
+		tag, err := c.Conn.Exec(ctx, query, expireDate)
+		if err != nil {
+			return count, err
+		}
+		count += tag.RowsAffected()
+	}
+	return count, nil
+}
+
+// ReadUnusedImageKeys - the keys of the stored photos which only archived
+//  images have, their files can be deleted
+func (c *Connector) ReadUnusedImageKeys(ctx context.Context, limit int) ([]string, error) {
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT DISTINCT ia.key
+		FROM image_archive ia
+		WHERE ia.key != ''
+			AND NOT EXISTS (SELECT 1 FROM image im WHERE im.key = ia.key)
+		LIMIT $1;`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := make([]string, 0)
+	for rows.Next() {
+		key := ""
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// ForgetImageKeys - the files of the photos are deleted, the archived images
+//  keep only the links to the sites
+func (c *Connector) ForgetImageKeys(ctx context.Context, keys []string) error {
+	_, err := c.Conn.Exec(ctx, `UPDATE image_archive SET key = '' WHERE key = ANY($1);`, keys)
+	return err
+}
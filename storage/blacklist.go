@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// BlacklistPhone - adds the normalized phone to the blacklist of the chat,
+//  structs.GlobalBlacklist hides the phone for everyone
+func (c *Connector) BlacklistPhone(ctx context.Context, chatId int64, phone, reason string) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`INSERT INTO blacklist (chat, phone, reason, created)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chat, phone) DO UPDATE SET reason = excluded.reason;`,
+		chatId,
+		phone,
+		reason,
+		time.Now().Unix(),
+	)
+	return err
+}
+
+// ReadBlacklist - the phones in the blacklist of the chat, the last added
+//  first
+func (c *Connector) ReadBlacklist(ctx context.Context, chatId int64) ([]*structs.BlacklistEntry, error) {
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT id, chat, phone, reason, created FROM blacklist WHERE chat = $1 ORDER BY id DESC;`,
+		chatId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := make([]*structs.BlacklistEntry, 0)
+	for rows.Next() {
+		entry := new(structs.BlacklistEntry)
+		err := rows.Scan(&entry.Id, &entry.Chat, &entry.Phone, &entry.Reason, &entry.Created)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// DeleteBlacklist - removes the phone from the blacklist of the chat by id
+func (c *Connector) DeleteBlacklist(ctx context.Context, chatId, id int64) error {
+	_, err := c.Conn.Exec(ctx, `DELETE FROM blacklist WHERE chat = $1 AND id = $2;`, chatId, id)
+	return err
+}
+
+// DeleteBlacklistPhone - removes the phone from the blacklist of the chat
+func (c *Connector) DeleteBlacklistPhone(ctx context.Context, chatId int64, phone string) error {
+	_, err := c.Conn.Exec(ctx, `DELETE FROM blacklist WHERE chat = $1 AND phone = $2;`, chatId, phone)
+	return err
+}
@@ -51,12 +51,16 @@ func (c *Connector) ReadChat(ctx context.Context, id int64) (*structs.Chat, erro
 		c_type,
 		created,
 		enable,
+		vote_rule,
+		timezone,
 		diesel,
 		house,
 		lalafo,
 		photo,
+		hide_suspicious,
 		usd,
-		kgs
+		kgs,
+		rooms
 	FROM chat
 	WHERE id = $1
 	`,
@@ -68,12 +72,16 @@ func (c *Connector) ReadChat(ctx context.Context, id int64) (*structs.Chat, erro
 		&chat.Type,
 		&chat.Created,
 		&chat.Enable,
+		&chat.VoteRule,
+		&chat.Timezone,
 		&chat.Diesel,
 		&chat.House,
 		&chat.Lalafo,
 		&chat.Photo,
+		&chat.HideSuspicious,
 		&chat.USD,
 		&chat.KGS,
+		&chat.Rooms,
 	)
 	return chat, err
 }
@@ -90,12 +98,16 @@ func (c *Connector) ReadChatsForMatching(ctx context.Context, enable int) ([]*st
 		c.c_type,
 		c.created,
 		c.enable,
+		c.vote_rule,
+		c.timezone,
 		c.diesel,
 		c.house,
 		c.lalafo,
 		c.photo,
+		c.hide_suspicious,
 		c.usd,
-		c.kgs
+		c.kgs,
+		c.rooms
 	FROM chat c
 `)
 
@@ -123,12 +135,16 @@ func (c *Connector) ReadChatsForMatching(ctx context.Context, enable int) ([]*st
 			&chat.Type,
 			&chat.Created,
 			&chat.Enable,
+			&chat.VoteRule,
+			&chat.Timezone,
 			&chat.Diesel,
 			&chat.House,
 			&chat.Lalafo,
 			&chat.Photo,
+			&chat.HideSuspicious,
 			&chat.USD,
 			&chat.KGS,
+			&chat.Rooms,
 		)
 		if err != nil {
 			log.Println("[ReadChatsForMatching.Scan] error:", err)
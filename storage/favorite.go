@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// favoriteOffer - the condition for `offer of` to be in someone's favorites
+const favoriteOffer = `SELECT 1 FROM answer a WHERE a.offer_id = of.id AND a.liked`
+
+// Like - adds the offer to favorites of the chat
+func (c *Connector) Like(ctx context.Context, offerId uint64, chatId int64) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`INSERT INTO answer (chat, offer_id, liked, created) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chat, offer_id) DO UPDATE SET liked = true, dislike = false, created = excluded.created;`,
+		chatId,
+		offerId,
+		true,
+		time.Now().Unix(),
+	)
+	return err
+}
+
+// Unlike - removes the offer from favorites of the chat
+func (c *Connector) Unlike(ctx context.Context, offerId uint64, chatId int64) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`UPDATE answer SET liked = false WHERE chat = $1 AND offer_id = $2;`,
+		chatId,
+		offerId,
+	)
+	return err
+}
+
+// IsLiked - the offer is in favorites of the chat
+func (c *Connector) IsLiked(ctx context.Context, offerId uint64, chatId int64) (bool, error) {
+	liked := false
+	err := c.Conn.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM answer WHERE chat = $1 AND offer_id = $2 AND liked);`,
+		chatId,
+		offerId,
+	).Scan(&liked)
+	return liked, err
+}
+
+// ReadFavorites - returns the page of favorites of the chat, the last liked
+//  first, and the number of all favorites
+func (c *Connector) ReadFavorites(ctx context.Context, chatId int64, offset, limit int) ([]*structs.Offer, int, error) {
+	total := 0
+	err := c.Conn.QueryRow(
+		ctx,
+		`SELECT count(*)
+		FROM offer of
+		JOIN answer fa on (fa.offer_id = of.id AND fa.chat = $1 AND fa.liked);`,
+		chatId,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT `+offerColumns+`
+		FROM offer of `+marketJoin+`
+		JOIN answer fa on (fa.offer_id = of.id AND fa.chat = $1 AND fa.liked)
+		ORDER BY fa.created DESC, of.id
+		OFFSET $2 LIMIT $3;`,
+		chatId,
+		offset,
+		limit,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	offers := make([]*structs.Offer, 0, limit)
+	for rows.Next() {
+		offer := new(structs.Offer)
+		if err := rows.Scan(offerFields(offer)...); err != nil {
+			return nil, 0, err
+		}
+		offer.Liked = true
+		offers = append(offers, offer)
+	}
+	return offers, total, rows.Err()
+}
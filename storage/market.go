@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/comov/hsearch/scam"
+	"github.com/comov/hsearch/structs"
+)
+
+// marketMinSamples - the median of fewer offers is not shown on the card
+//  and in the statistics
+const marketMinSamples = 5
+
+// marketJoin - the market statistics of the offer card, the median is read
+//  once by the key columns of the offer
+var marketJoin = `
+	LEFT JOIN market_stat ms on (
+		ms.city = of.city
+		AND ms.district = of.district
+		AND ms.rooms = of.rooms
+		AND ms.currency = of.currency
+		AND ms.samples >= ` + strconv.Itoa(marketMinSamples) + `
+	)`
+
+// UpdateMarketStats - counts the percentiles of the price of the offers
+//  created after since by city, district, rooms and currency. The archived
+//  offers are counted too. One flat from several sites is counted once, hidden
+//  and suspicious offers are skipped.
+func (c *Connector) UpdateMarketStats(ctx context.Context, since, now int64) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`INSERT INTO market_stat (city, district, rooms, currency, p25, median, p75, samples, updated)
+		SELECT
+			city,
+			district,
+			rooms,
+			currency,
+			percentile_disc(0.25) WITHIN GROUP (ORDER BY price),
+			percentile_disc(0.5) WITHIN GROUP (ORDER BY price),
+			percentile_disc(0.75) WITHIN GROUP (ORDER BY price),
+			count(*),
+			$2
+		FROM (
+			SELECT DISTINCT ON (of.cluster_id)
+				of.city,
+				of.district,
+				of.rooms,
+				of.currency,
+				of.price
+			FROM (`+withArchive+`) of
+			WHERE of.created >= $1
+				AND of.price > 0
+				AND of.district != ''
+				AND of.rooms != 0
+				AND NOT of.hidden
+				AND of.scam_score < $3
+			ORDER BY of.cluster_id, of.created DESC
+		) prices
+		GROUP BY city, district, rooms, currency
+		ON CONFLICT (city, district, rooms, currency) DO UPDATE SET
+			p25 = excluded.p25,
+			median = excluded.median,
+			p75 = excluded.p75,
+			samples = excluded.samples,
+			updated = excluded.updated;`,
+		since,
+		now,
+		scam.Suspicious,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Conn.Exec(ctx, `DELETE FROM market_stat WHERE updated < $1;`, now)
+	return err
+}
+
+// ReadMarketStats - the prices of the flats which pass the filters of the
+//  chat, only the currencies and rooms of the filters
+func (c *Connector) ReadMarketStats(ctx context.Context, filters structs.Filters) ([]*structs.MarketStat, error) {
+	currencies := make([]string, 0, 2)
+	if filters.USD[0] >= 0 {
+		currencies = append(currencies, "usd")
+	}
+	if filters.KGS[0] >= 0 {
+		currencies = append(currencies, "kgs")
+	}
+
+	var query strings.Builder
+	query.WriteString(`
+	SELECT city, district, rooms, currency, p25, median, p75, samples
+	FROM market_stat
+	WHERE samples >= $1 AND currency = ANY($2)`)
+
+	rooms := filters.Rooms
+	switch {
+	case rooms[0] == 0 && rooms[1] == 0:
+	case rooms[1] == 0:
+		query.WriteString(fmt.Sprintf(" AND rooms >= %d", rooms[0]))
+	default:
+		query.WriteString(fmt.Sprintf(" AND rooms between %d and %d", rooms[0], rooms[1]))
+	}
+	query.WriteString(" ORDER BY currency DESC, rooms, samples DESC, district;")
+
+	rows, err := c.Conn.Query(ctx, query.String(), marketMinSamples, currencies)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	stats := make([]*structs.MarketStat, 0)
+	for rows.Next() {
+		stat := new(structs.MarketStat)
+		err := rows.Scan(
+			&stat.City,
+			&stat.District,
+			&stat.Rooms,
+			&stat.Currency,
+			&stat.P25,
+			&stat.Median,
+			&stat.P75,
+			&stat.Samples,
+		)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// ReadMarketMedians - the median prices of the market statistics for the
+//  scam scoring, see scam.MedianKey
+func (c *Connector) ReadMarketMedians(ctx context.Context) (map[string]scam.Median, error) {
+	rows, err := c.Conn.Query(ctx, `SELECT city, district, rooms, currency, median, samples FROM market_stat;`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	medians := make(map[string]scam.Median)
+	for rows.Next() {
+		var city, district, currency string
+		var rooms int
+		median := scam.Median{}
+		err := rows.Scan(&city, &district, &rooms, &currency, &median.Price, &median.Samples)
+		if err != nil {
+			return nil, err
+		}
+		medians[scam.MedianKey(city, district, rooms, currency)] = median
+	}
+	return medians, rows.Err()
+}
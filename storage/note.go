@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// AddNote - attaches the note of the chat member to the offer
+func (c *Connector) AddNote(ctx context.Context, chatId int64, offerId uint64, note *structs.Note) error {
+	note.Created = time.Now().Unix()
+	return c.Conn.QueryRow(
+		ctx,
+		`INSERT INTO note (chat, offer_id, user_id, username, body, created)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id;`,
+		chatId,
+		offerId,
+		note.User,
+		note.Username,
+		note.Body,
+		note.Created,
+	).Scan(&note.Id)
+}
+
+// ReadNotes - the notes of the chat for the offers, the oldest first
+func (c *Connector) ReadNotes(ctx context.Context, chatId int64, offerIds ...uint64) (map[uint64][]*structs.Note, error) {
+	notes := make(map[uint64][]*structs.Note)
+	if len(offerIds) == 0 {
+		return notes, nil
+	}
+
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT id, offer_id, user_id, username, body, created
+		FROM note
+		WHERE chat = $1 AND offer_id = ANY($2)
+		ORDER BY id;`,
+		chatId,
+		offerIds,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var offerId uint64
+		note := new(structs.Note)
+		err := rows.Scan(&note.Id, &offerId, &note.User, &note.Username, &note.Body, &note.Created)
+		if err != nil {
+			return nil, err
+		}
+		notes[offerId] = append(notes[offerId], note)
+	}
+	return notes, rows.Err()
+}
+
+// CleanExpiredNotes - clean note table, the notes of favorites are kept as
+//  long as the offer is in favorites
+func (c *Connector) CleanExpiredNotes(ctx context.Context, expireDate int64) (int64, error) {
+	tag, err := c.Conn.Exec(
+		ctx,
+		`DELETE FROM note n
+		WHERE n.created < $1
+			AND NOT EXISTS(
+				SELECT 1 FROM answer a WHERE a.chat = n.chat AND a.offer_id = n.offer_id AND a.liked
+			);`,
+		expireDate,
+	)
+	return tag.RowsAffected(), err
+}
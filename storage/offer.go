@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"log"
 	"strconv"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/jackc/pgx/v4"
 
+	"github.com/comov/hsearch/dedup"
+	"github.com/comov/hsearch/scam"
 	"github.com/comov/hsearch/structs"
 )
 
@@ -33,7 +36,14 @@ func (c *Connector) WriteOffer(ctx context.Context, offer *structs.Offer) error
 		city,
 		room_type,
 		body,
-		images) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17);`,
+		images,
+		cluster_id,
+		phone_norm,
+		signature,
+		scam_score,
+		scam_reasons,
+		same_photos,
+		rooms) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24);`,
 		offer.Id,
 		time.Now().Unix(),
 		offer.Site,
@@ -51,11 +61,39 @@ func (c *Connector) WriteOffer(ctx context.Context, offer *structs.Offer) error
 		offer.RoomType,
 		offer.Body,
 		offer.Images,
+		clusterId(offer),
+		offer.PhoneNorm,
+		encodeSignature(offer.Signature),
+		offer.ScamScore,
+		scamReasons(offer),
+		offer.SamePhotos,
+		dedup.RoomsNumber(offer.Rooms),
 	)
 	if err != nil && !regexContain.MatchString(err.Error()) {
 		return err
 	}
-	return c.writeImages(ctx, strconv.Itoa(int(offer.Id)), offer.ImagesList)
+	return c.writeImages(ctx, strconv.Itoa(int(offer.Id)), offerImages(offer))
+}
+
+// scamReasons - NULL can not be scanned into []string
+func scamReasons(offer *structs.Offer) []string {
+	if offer.ScamReasons == nil {
+		return []string{}
+	}
+	return offer.ScamReasons
+}
+
+// offerImages - if the photos were not loaded, write them without hashes
+func offerImages(offer *structs.Offer) []*structs.Image {
+	if len(offer.Photos) != 0 {
+		return offer.Photos
+	}
+
+	images := make([]*structs.Image, 0, len(offer.ImagesList))
+	for _, path := range offer.ImagesList {
+		images = append(images, &structs.Image{Path: path})
+	}
+	return images
 }
 
 // WriteOffers - writes bulk from offers along with pictures to the fd.
@@ -78,7 +116,7 @@ func (c *Connector) WriteOffers(ctx context.Context, offers []*structs.Offer) (i
 
 // writeImages - так как картинки храняться в отдельной таблице, то пишем мы их
 // отдельно
-func (c *Connector) writeImages(ctx context.Context, offerId string, images []string) error {
+func (c *Connector) writeImages(ctx context.Context, offerId string, images []*structs.Image) error {
 	if len(images) <= 0 {
 		return nil
 	}
@@ -90,13 +128,13 @@ func (c *Connector) writeImages(ctx context.Context, offerId string, images []st
 	paramsNum := 1
 	sep := ""
 	for _, image := range images {
-		paramsPattern += sep + fmt.Sprintf("($%d, $%d, $%d)", paramsNum, paramsNum+1, paramsNum+2) // todo: fixed
+		paramsPattern += sep + fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", paramsNum, paramsNum+1, paramsNum+2, paramsNum+3, paramsNum+4, paramsNum+5) // todo: fixed
 		sep = ", "
-		params = append(params, offerId, image, now)
-		paramsNum += 3
+		params = append(params, offerId, image.Path, int64(image.Hash), dedup.PhotoBands(image.Hash), image.Key, now)
+		paramsNum += 6
 	}
 
-	query := "INSERT INTO image (offer_id, path, created) VALUES " + paramsPattern
+	query := "INSERT INTO image (offer_id, path, hash, bands, key, created) VALUES " + paramsPattern
 	_, err := c.Conn.Exec(ctx, query, params...)
 	if err != nil && !regexContain.MatchString(err.Error()) {
 		return err
@@ -152,11 +190,10 @@ func (c *Connector) CleanFromExistOrders(ctx context.Context, offers map[uint64]
 	return nil
 }
 
-// Dislike - mark offer as bad for user or group and return all message ids
-//  (description and photos) for delete from chat.
-func (c *Connector) Dislike(ctx context.Context, msgId int, chatId int64) ([]int, error) {
+// ReadOfferIdByMessage - returns the offer which was sent in the message.
+//  Needed for the old buttons without the offer id in the data.
+func (c *Connector) ReadOfferIdByMessage(ctx context.Context, msgId int, chatId int64) (uint64, error) {
 	offerId := uint64(0)
-	msgIds := make([]int, 0)
 	err := c.Conn.QueryRow(
 		ctx,
 		`SELECT offer_id
@@ -168,19 +205,51 @@ func (c *Connector) Dislike(ctx context.Context, msgId int, chatId int64) ([]int
 	).Scan(
 		&offerId,
 	)
-	if err != nil {
-		return msgIds, err
+	return offerId, err
+}
+
+// ReadMessageProfile - the name of the search the card was sent for, empty
+//  if the card was sent by the chat filters or is already forgotten
+func (c *Connector) ReadMessageProfile(ctx context.Context, msgId int, chatId int64) (string, error) {
+	profile := ""
+	err := c.Conn.QueryRow(
+		ctx,
+		`SELECT profile
+				FROM tg_messages
+				WHERE message_id = $1
+					AND chat = $2
+				ORDER BY id
+				LIMIT 1;`,
+		msgId,
+		chatId,
+	).Scan(
+		&profile,
+	)
+	if err == pgx.ErrNoRows {
+		return "", nil
 	}
+	return profile, err
+}
+
+// Dislike - mark offer as bad for user or group and return all message ids
+//  (description and photos) for delete from chat.
+func (c *Connector) Dislike(ctx context.Context, offerId uint64, chatId int64) ([]int, error) {
+	msgIds := make([]int, 0)
 
-	_, _ = c.Conn.Exec(
+	// the disliked offer leaves favorites
+	_, err := c.Conn.Exec(
 		ctx,
 		`INSERT INTO answer (chat, offer_id, dislike, created)
-				VALUES ($1, $2, $3, $4);`,
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (chat, offer_id) DO UPDATE SET dislike = true, liked = false, created = excluded.created;`,
 		chatId,
 		offerId,
 		true,
 		time.Now().Unix(),
 	)
+	if err != nil {
+		return msgIds, err
+	}
 
 	// load all message with offerId and delete
 	rows, err := c.Conn.Query(
@@ -212,13 +281,316 @@ func (c *Connector) Dislike(ctx context.Context, msgId int, chatId int64) ([]int
 	return msgIds, err
 }
 
-func (c *Connector) ReadNextOffer(ctx context.Context, chat *structs.Chat) (*structs.Offer, error) {
+// Undislike - removes the dislike of the offer and forgets the deleted
+//  messages of the offer, so the card can be sent again.
+func (c *Connector) Undislike(ctx context.Context, offerId uint64, chatId int64) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`DELETE FROM answer WHERE chat = $1 AND offer_id = $2 AND dislike is true;`,
+		chatId,
+		offerId,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Conn.Exec(
+		ctx,
+		`DELETE FROM tg_messages WHERE chat = $1 AND offer_id = $2;`,
+		chatId,
+		offerId,
+	)
+	return err
+}
+
+// SetDislikeReason - remembers why the chat disliked the offer
+func (c *Connector) SetDislikeReason(ctx context.Context, offerId uint64, chatId int64, reason string) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`UPDATE answer SET reason = $1 WHERE chat = $2 AND offer_id = $3 AND dislike is true;`,
+		reason,
+		chatId,
+		offerId,
+	)
+	return err
+}
+
+// ReadDislikedByReason - returns the price, the currency and the phone of the
+//  offers the chat disliked with the reason
+func (c *Connector) ReadDislikedByReason(ctx context.Context, chatId int64, reason string) ([]*structs.Offer, error) {
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT DISTINCT of.id, of.price, of.currency, of.phone_norm
+		FROM answer a
+		JOIN offer of on (of.id = a.offer_id)
+		WHERE a.chat = $1 AND a.dislike is true AND a.reason = $2;`,
+		chatId,
+		reason,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	offers := make([]*structs.Offer, 0)
+	for rows.Next() {
+		offer := new(structs.Offer)
+		if err := rows.Scan(&offer.Id, &offer.Price, &offer.Currency, &offer.PhoneNorm); err != nil {
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+	return offers, rows.Err()
+}
+
+// ReadDisliked - returns the last disliked offers of the chat
+func (c *Connector) ReadDisliked(ctx context.Context, chatId int64, limit int) ([]*structs.Offer, error) {
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT `+offerColumns+`
+		FROM offer of `+marketJoin+`
+		JOIN (
+			SELECT offer_id, max(created) AS disliked_at
+			FROM answer
+			WHERE chat = $1 AND dislike is true
+			GROUP BY offer_id
+		) da on (da.offer_id = of.id)
+		ORDER BY da.disliked_at DESC, of.id
+		LIMIT $2;`,
+		chatId,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	offers := make([]*structs.Offer, 0, limit)
+	for rows.Next() {
+		offer := new(structs.Offer)
+		if err := rows.Scan(offerFields(offer)...); err != nil {
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+	return offers, rows.Err()
+}
+
+// ReadClusterCandidates - reads fresh offers which have the same phone, the
+//  same price with the same currency and rooms or a similar photo as the new
+//  offers, to check them for duplicates. The offers without rooms are found
+//  only by the phone and the photos, the price alone matches too many
+//  offers. The photos are found by the index of their bands, see
+//  dedup.PhotoBands.
+func (c *Connector) ReadClusterCandidates(ctx context.Context, offers []*structs.Offer, since int64) ([]*structs.Offer, error) {
+	phones := make([]string, 0, len(offers))
+	prices := make([]int, 0, len(offers))
+	currencies := make([]string, 0, len(offers))
+	rooms := make([]int, 0, len(offers))
+	hashes := make([]int64, 0)
+	photos := make([]uint64, 0)
+	for _, offer := range offers {
+		if offer.PhoneNorm != "" {
+			phones = append(phones, offer.PhoneNorm)
+		}
+		if n := dedup.RoomsNumber(offer.Rooms); offer.Price != 0 && n != 0 {
+			prices = append(prices, offer.Price)
+			currencies = append(currencies, offer.Currency)
+			rooms = append(rooms, n)
+		}
+		for _, hash := range offer.PhotoHashes() {
+			hashes = append(hashes, int64(hash))
+			photos = append(photos, hash)
+		}
+	}
+
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT
+		of.id,
+		of.site,
+		of.price,
+		of.currency,
+		of.room_numbers,
+		of.area,
+		of.body,
+		of.cluster_id,
+		of.phone_norm,
+		of.signature,
+		of.same_photos
+	FROM offer of
+	WHERE of.created >= $1
+		AND (
+			of.phone_norm = ANY($2)
+			OR (of.price, of.currency, of.rooms) IN (
+				SELECT * FROM unnest($3::int[], $4::text[], $5::int[])
+			)
+			OR of.id IN (
+				SELECT im.offer_id
+				FROM image im
+				WHERE im.bands && $7::int[]
+					AND im.created >= $1
+					AND EXISTS (
+						SELECT 1 FROM unnest($6::bigint[]) h WHERE `+samePhoto("im.hash", "h")+`
+					)
+			)
+		)
+	ORDER BY of.created;`,
+		since,
+		phones,
+		prices,
+		currencies,
+		rooms,
+		hashes,
+		dedup.NearPhotoBands(photos),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	candidates := make([]*structs.Offer, 0)
+	for rows.Next() {
+		offer := new(structs.Offer)
+		signature := make([]byte, 0)
+		err := rows.Scan(
+			&offer.Id,
+			&offer.Site,
+			&offer.Price,
+			&offer.Currency,
+			&offer.Rooms,
+			&offer.Area,
+			&offer.Body,
+			&offer.ClusterId,
+			&offer.PhoneNorm,
+			&signature,
+			&offer.SamePhotos,
+		)
+		if err != nil {
+			log.Println("[ReadClusterCandidates.Scan] error:", err)
+			continue
+		}
+
+		offer.Signature = decodeSignature(signature)
+		candidates = append(candidates, offer)
+	}
+
+	return candidates, c.readPhotoHashes(ctx, candidates)
+}
+
+// UpdateSamePhotos - saves the grown counts of other clusters with the same
+//  photos, see dedup.CountSamePhotos
+func (c *Connector) UpdateSamePhotos(ctx context.Context, offers []*structs.Offer) error {
+	for _, offer := range offers {
+		_, err := c.Conn.Exec(
+			ctx,
+			`UPDATE offer SET same_photos = greatest(same_photos, $2) WHERE id = $1;`,
+			offer.Id,
+			offer.SamePhotos,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPhotoHashes - fills Photos of the offers with the hashed images
+func (c *Connector) readPhotoHashes(ctx context.Context, offers []*structs.Offer) error {
+	if len(offers) == 0 {
+		return nil
+	}
+
+	byId := make(map[uint64]*structs.Offer, len(offers))
+	ids := make([]int64, 0, len(offers))
+	for _, offer := range offers {
+		byId[offer.Id] = offer
+		ids = append(ids, int64(offer.Id))
+	}
+
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT offer_id, path, hash FROM image WHERE offer_id = ANY($1) AND hash != 0;`,
+		ids,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		offerId, hash := uint64(0), int64(0)
+		img := new(structs.Image)
+		if err := rows.Scan(&offerId, &img.Path, &hash); err != nil {
+			log.Println("[readPhotoHashes.Scan] error:", err)
+			continue
+		}
+
+		img.Hash = uint64(hash)
+		if offer, ok := byId[offerId]; ok {
+			offer.Photos = append(offer.Photos, img)
+		}
+	}
+
+	return nil
+}
+
+// readClusterLinks - returns urls of the other offers from the offer cluster
+func (c *Connector) readClusterLinks(ctx context.Context, offer *structs.Offer) ([]string, error) {
+	links := make([]string, 0)
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT url FROM offer WHERE cluster_id = $1 AND id != $2 ORDER BY created;`,
+		offer.ClusterId,
+		offer.Id,
+	)
+	if err != nil {
+		return links, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		link := ""
+		if err := rows.Scan(&link); err != nil {
+			log.Println("[readClusterLinks.Scan] error:", err)
+			continue
+		}
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// ReadOffer - returns the offer card or nil if the offer was deleted
+func (c *Connector) ReadOffer(ctx context.Context, offerId uint64) (*structs.Offer, error) {
 	offer := new(structs.Offer)
-	now := time.Now()
+	err := c.Conn.QueryRow(
+		ctx,
+		`SELECT `+offerColumns+` FROM offer of `+marketJoin+` WHERE of.id = $1;`,
+		offerId,
+	).Scan(offerFields(offer)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
 
-	var query strings.Builder
-	query.WriteString(`
-	SELECT
+	offer.Links, err = c.readClusterLinks(ctx, offer)
+	return offer, err
+}
+
+// offerColumns - the columns of the offer card, the query joins marketJoin
+//  for MarketMedian, the median price of the same flats in the district.
+//  SamePhotos is the number of
+//  other clusters with the same photos, it is counted when the offers are
+//  written, see dedup.CountSamePhotos
+var offerColumns = `
 		of.id,
 		of.site,
 		of.url,
@@ -234,25 +606,102 @@ func (c *Connector) ReadNextOffer(ctx context.Context, chat *structs.Chat) (*str
 		of.district,
 		of.room_type,
 		of.images,
-		of.body
-	FROM offer of
-	LEFT JOIN answer u on (of.id = u.offer_id AND u.chat = $1)
-	LEFT JOIN tg_messages sm on (of.id = sm.offer_id AND sm.chat = $2)
+		of.body,
+		of.cluster_id,
+		of.scam_score,
+		of.scam_reasons,
+		coalesce(ms.median, 0),
+		of.same_photos`
+
+// samePhoto - the SQL condition for two photo hashes to be the same photo,
+//  the number of different bits is counted like dedup.Distance does
+func samePhoto(a, b string) string {
+	return fmt.Sprintf("length(replace((%s # %s)::bit(64)::text, '0', '')) <= %d", a, b, dedup.MaxPhotoDistance)
+}
+
+// offerFields - where to scan offerColumns
+func offerFields(offer *structs.Offer) []interface{} {
+	return []interface{}{
+		&offer.Id,
+		&offer.Site,
+		&offer.Url,
+		&offer.Topic,
+		&offer.FullPrice,
+		&offer.Price,
+		&offer.Currency,
+		&offer.Phone,
+		&offer.Rooms,
+		&offer.Area,
+		&offer.City,
+		&offer.Floor,
+		&offer.District,
+		&offer.RoomType,
+		&offer.Images,
+		&offer.Body,
+		&offer.ClusterId,
+		&offer.ScamScore,
+		&offer.ScamReasons,
+		&offer.MarketMedian,
+		&offer.SamePhotos,
+	}
+}
+
+// ReadNextOffer - returns the oldest fresh offer which suits the chat filters.
+//  An offer is skipped if the chat has already received or disliked any offer
+//  from its cluster, if it is hidden by the admin or its phone is in the
+//  blacklist of the chat or in the global one.
+func (c *Connector) ReadNextOffer(ctx context.Context, chat *structs.Chat) (*structs.Offer, error) {
+	offer := new(structs.Offer)
+	now := time.Now()
+
+	var query strings.Builder
+	query.WriteString(`
+	SELECT ` + offerColumns + `
+	FROM offer of ` + marketJoin + `
 	WHERE of.created >= $3
-		AND (u.dislike is false OR u.dislike IS NULL)
-		AND sm.created IS NULL
+		AND NOT of.hidden
+		AND NOT EXISTS (
+			SELECT 1
+			FROM blacklist bl
+			WHERE bl.chat IN (0, $1)
+				AND bl.phone = of.phone_norm
+				AND of.phone_norm != ''
+		)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM answer u
+			JOIN offer co on (co.id = u.offer_id)
+			WHERE u.chat = $1
+				AND u.dislike is true
+				AND co.cluster_id = of.cluster_id
+		)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM tg_messages sm
+			JOIN offer co on (co.id = sm.offer_id)
+			WHERE sm.chat = $2
+				AND co.cluster_id = of.cluster_id
+		)
 	`)
 
 	if chat.Photo {
 		query.WriteString(" AND of.images != 0")
 	}
 
+	if chat.HideSuspicious {
+		query.WriteString(fmt.Sprintf(" AND of.scam_score < %d", scam.Suspicious))
+	}
+
 	if chat.KGS.String() != "0:0" || chat.USD.String() != "0:0" {
 		query.WriteString(priceFilter(chat.USD, chat.KGS))
 	}
 
+	if chat.Rooms.String() != "0:0" {
+		query.WriteString(roomsFilter(chat.Rooms))
+	}
+
 	query.WriteString(siteFilter(chat.Diesel, chat.House, chat.Lalafo))
-	query.WriteString(" 	ORDER BY of.created;")
+	query.WriteString(" 	ORDER BY of.created LIMIT 1;")
 
 	err := c.Conn.QueryRow(
 		ctx,
@@ -260,49 +709,74 @@ func (c *Connector) ReadNextOffer(ctx context.Context, chat *structs.Chat) (*str
 		chat.Id,
 		chat.Id,
 		now.Add(-c.relevanceTime).Unix(),
-	).Scan(
-		&offer.Id,
-		&offer.Site,
-		&offer.Url,
-		&offer.Topic,
-		&offer.FullPrice,
-		&offer.Price,
-		&offer.Currency,
-		&offer.Phone,
-		&offer.Rooms,
-		&offer.Area,
-		&offer.City,
-		&offer.Floor,
-		&offer.District,
-		&offer.RoomType,
-		&offer.Images,
-		&offer.Body,
-	)
+	).Scan(offerFields(offer)...)
 
 	if err != nil && err == pgx.ErrNoRows {
 		return nil, nil
 	}
 
+	if err != nil {
+		return nil, err
+	}
+
+	offer.Links, err = c.readClusterLinks(ctx, offer)
 	return offer, err
 }
 
-func priceFilter(usd, kgs structs.Price) string {
-	var f strings.Builder
-	f.WriteString(" AND(")
-	if usd.String() == "0:0" {
-		f.WriteString(" of.currency = 'usd'")
-	} else {
-		f.WriteString(fmt.Sprintf(" (of.price between %d and %d and of.currency = 'usd')", usd[0], usd[1]))
+// clusterId - an offer without duplicates is a cluster itself
+func clusterId(offer *structs.Offer) uint64 {
+	if offer.ClusterId == 0 {
+		return offer.Id
 	}
+	return offer.ClusterId
+}
 
-	if kgs.String() == "0:0" {
-		f.WriteString(" or of.currency = 'kgs'")
-	} else {
-		f.WriteString(fmt.Sprintf(" or (of.price between %d and %d and of.currency = 'kgs')", kgs[0], kgs[1]))
+// encodeSignature - MinHash signature is stored as bytea
+func encodeSignature(signature []uint32) []byte {
+	if len(signature) == 0 {
+		return nil
 	}
 
-	f.WriteString(" )")
-	return f.String()
+	data := make([]byte, len(signature)*4)
+	for i, v := range signature {
+		binary.LittleEndian.PutUint32(data[i*4:], v)
+	}
+	return data
+}
+
+func decodeSignature(data []byte) []uint32 {
+	signature := make([]uint32, len(data)/4)
+	for i := range signature {
+		signature[i] = binary.LittleEndian.Uint32(data[i*4:])
+	}
+	return signature
+}
+
+func priceFilter(usd, kgs structs.Price) string {
+	return fmt.Sprintf(" AND (%s or %s)", currencyFilter("usd", usd), currencyFilter("kgs", kgs))
+}
+
+// currencyFilter - Price{0, 0} is any price, Price{from, 0} has no upper
+//  bound and a negative price turns off the currency
+func currencyFilter(currency string, p structs.Price) string {
+	switch {
+	case p[0] < 0 || p[1] < 0:
+		return "false"
+	case p[0] == 0 && p[1] == 0:
+		return fmt.Sprintf("of.currency = '%s'", currency)
+	case p[1] == 0:
+		return fmt.Sprintf("(of.price >= %d and of.currency = '%s')", p[0], currency)
+	}
+	return fmt.Sprintf("(of.price between %d and %d and of.currency = '%s')", p[0], p[1], currency)
+}
+
+// roomsFilter - the number of rooms is counted when the offer is written,
+//  offers without it do not pass the filter
+func roomsFilter(rooms structs.Price) string {
+	if rooms[1] == 0 {
+		return fmt.Sprintf(" AND of.rooms != 0 AND of.rooms >= %d", rooms[0])
+	}
+	return fmt.Sprintf(" AND of.rooms != 0 AND of.rooms between %d and %d", rooms[0], rooms[1])
 }
 
 func siteFilter(diesel, house, lalafo bool) string {
@@ -318,7 +792,7 @@ func siteFilter(diesel, house, lalafo bool) string {
 	}
 	switch len(sites) {
 	case 1:
-		return fmt.Sprintf(" AND of.site == '%s'", sites[0])
+		return fmt.Sprintf(" AND of.site = '%s'", sites[0])
 	case 2:
 		sitesStr, sep := "", ""
 		for _, site := range sites {
@@ -330,22 +804,9 @@ func siteFilter(diesel, house, lalafo bool) string {
 	return ""
 }
 
-func (c *Connector) ReadOfferDescription(ctx context.Context, msgId int, chatId int64) (uint64, string, error) {
-	offerId := uint64(0)
-	err := c.Conn.QueryRow(
-		ctx,
-		"SELECT offer_id FROM tg_messages WHERE message_id = $1 AND chat = $2;",
-		msgId,
-		chatId,
-	).Scan(
-		&offerId,
-	)
-	if err != nil {
-		return offerId, "", err
-	}
-
+func (c *Connector) ReadOfferDescription(ctx context.Context, offerId uint64) (string, error) {
 	description := ""
-	err = c.Conn.QueryRow(ctx, `SELECT body FROM offer of WHERE of.id = $1;`,
+	err := c.Conn.QueryRow(ctx, `SELECT body FROM offer of WHERE of.id = $1;`,
 		offerId,
 	).Scan(
 		&description,
@@ -353,69 +814,122 @@ func (c *Connector) ReadOfferDescription(ctx context.Context, msgId int, chatId
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return offerId, "Предложение не найдено, возможно было удалено", nil
+			return "Предложение не найдено, возможно было удалено", nil
 		}
-		return offerId, "", err
+		return "", err
 	}
 
-	return offerId, description, nil
+	return description, nil
 }
 
-func (c *Connector) ReadOfferImages(ctx context.Context, msgId int, chatId int64) (uint64, []string, error) {
-	offerId := uint64(0)
-	images := make([]string, 0)
-
-	err := c.Conn.QueryRow(
-		ctx,
-		"SELECT offer_id FROM tg_messages WHERE message_id = $1 AND chat = $2;",
-		msgId,
-		chatId,
-	).Scan(
-		&offerId,
-	)
-	if err != nil {
-		return offerId, images, err
-	}
-
-	rows, err := c.Conn.Query(ctx, `SELECT path FROM image im WHERE im.offer_id = $1;`, offerId)
+func (c *Connector) ReadOfferImages(ctx context.Context, offerId uint64) ([]*structs.Image, error) {
+	images := make([]*structs.Image, 0)
+	rows, err := c.Conn.Query(ctx, `SELECT path, hash, key, file_id FROM image im WHERE im.offer_id = $1 ORDER BY im.id;`, offerId)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return offerId, images, nil
+			return images, nil
 		}
-		return offerId, images, err
+		return images, err
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
-		image := ""
+		image := new(structs.Image)
+		hash := int64(0)
 		err := rows.Scan(
-			&image,
+			&image.Path,
+			&hash,
+			&image.Key,
+			&image.FileId,
 		)
 		if err != nil {
 			log.Println("[ReadOfferImages.Scan] error:", err)
 			continue
 		}
+		image.Hash = uint64(hash)
 		images = append(images, image)
 	}
 
-	return offerId, images, nil
+	return images, nil
 }
 
-// CleanExpiredOffers - just clean offer table
-func (c *Connector) CleanExpiredOffers(ctx context.Context, expireDate int64) error {
-	_, err := c.Conn.Exec(ctx, `DELETE FROM offer WHERE created < $1`, expireDate)
+// SaveImageFileId - remembers the id of the photo on Telegram servers, so
+//  the next time the photo is sent without downloading.
+func (c *Connector) SaveImageFileId(ctx context.Context, path, fileId string) error {
+	_, err := c.Conn.Exec(ctx, `UPDATE image SET file_id = $1 WHERE path = $2;`, fileId, path)
 	return err
 }
 
-// CleanExpiredImages - just clean image table
-func (c *Connector) CleanExpiredImages(ctx context.Context, expireDate int64) error {
-	_, err := c.Conn.Exec(ctx, `DELETE FROM image WHERE created < $1`, expireDate)
-	return err
+// keptOffer - the condition for `offer of` which the garbage collector keeps:
+//  the offer is in favorites, in the pipeline which is not finished, snoozed or
+//  has a viewing
+const keptOffer = favoriteOffer + `
+	UNION ALL
+	SELECT 1 FROM offer_status os
+	WHERE os.offer_id = of.id
+		AND os.status NOT IN ('` + structs.StatusRejected + `', '` + structs.StatusRented + `')
+	UNION ALL
+	SELECT 1 FROM snooze s WHERE s.offer_id = of.id
+	UNION ALL
+	SELECT 1 FROM viewing v WHERE v.offer_id = of.id`
+
+// CleanExpiredOffers - moves the old offers to offer_archive, the offers
+//  from keptOffer stay
+func (c *Connector) CleanExpiredOffers(ctx context.Context, expireDate int64) (int64, error) {
+	var count int64
+	err := c.Conn.QueryRow(
+		ctx,
+		`WITH moved AS (
+			DELETE FROM offer of WHERE of.created < $1 AND NOT EXISTS (`+keptOffer+`)
+			RETURNING of.*
+		), archived AS (
+			INSERT INTO offer_archive (`+offerArchiveColumns+`)
+			SELECT `+offerArchiveColumns+` FROM moved
+			ON CONFLICT DO NOTHING
+		)
+		SELECT count(*) FROM moved;`,
+		expireDate,
+	).Scan(&count)
+	return count, err
 }
 
-// CleanExpiredAnswers - just clean answer table
-func (c *Connector) CleanExpiredAnswers(ctx context.Context, expireDate int64) error {
-	_, err := c.Conn.Exec(ctx, `DELETE FROM answer WHERE created < $1`, expireDate)
-	return err
+// CleanExpiredImages - moves the old images to image_archive, the images of
+//  the offers from keptOffer stay
+func (c *Connector) CleanExpiredImages(ctx context.Context, expireDate int64) (int64, error) {
+	var count int64
+	err := c.Conn.QueryRow(
+		ctx,
+		`WITH moved AS (
+			DELETE FROM image im WHERE im.created < $1 AND NOT EXISTS (
+				SELECT 1 FROM offer of WHERE of.id = im.offer_id AND EXISTS (`+keptOffer+`)
+			)
+			RETURNING im.*
+		), archived AS (
+			INSERT INTO image_archive (`+imageArchiveColumns+`)
+			SELECT `+imageArchiveColumns+` FROM moved
+			ON CONFLICT DO NOTHING
+		)
+		SELECT count(*) FROM moved;`,
+		expireDate,
+	).Scan(&count)
+	return count, err
+}
+
+// CleanExpiredAnswers - moves the old answers to answer_archive, the likes
+//  are kept while the offer is in favorites
+func (c *Connector) CleanExpiredAnswers(ctx context.Context, expireDate int64) (int64, error) {
+	var count int64
+	err := c.Conn.QueryRow(
+		ctx,
+		`WITH moved AS (
+			DELETE FROM answer WHERE created < $1 AND liked is false RETURNING *
+		), archived AS (
+			INSERT INTO answer_archive (`+answerArchiveColumns+`)
+			SELECT `+answerArchiveColumns+` FROM moved
+		)
+		SELECT count(*) FROM moved;`,
+		expireDate,
+	).Scan(&count)
+	return count, err
 }
@@ -22,15 +22,17 @@ func (c *Connector) Feedback(ctx context.Context, chat int64, username, body str
 }
 
 // SaveMessage - when we send user or group offer, description or photos, we
-//  save this message for subsequent removal from chat, if need.
-func (c *Connector) SaveMessage(ctx context.Context, msgId int, offerId uint64, chat int64, kind string) error {
+//  save this message for subsequent removal from chat, if need. The profile
+//  is the search the card was sent for.
+func (c *Connector) SaveMessage(ctx context.Context, msgId int, offerId uint64, chat int64, kind, profile string) error {
 	_, err := c.Conn.Exec(
 		ctx,
-		`INSERT INTO tg_messages (message_id, offer_id, kind, chat, created) VALUES ($1, $2, $3, $4, $5);`,
+		`INSERT INTO tg_messages (message_id, offer_id, kind, chat, profile, created) VALUES ($1, $2, $3, $4, $5, $6);`,
 		msgId,
 		offerId,
 		kind,
 		chat,
+		profile,
 		time.Now().Unix(),
 	)
 
@@ -38,7 +40,13 @@ func (c *Connector) SaveMessage(ctx context.Context, msgId int, offerId uint64,
 }
 
 // CleanExpiredTGMessages - just clean tg_messages table
-func (c *Connector) CleanExpiredTGMessages(ctx context.Context, expireDate int64) error {
-	_, err := c.Conn.Exec(ctx, `DELETE FROM tg_messages WHERE created < $1`, expireDate)
-	return err
+func (c *Connector) CleanExpiredTGMessages(ctx context.Context, expireDate int64) (int64, error) {
+	tag, err := c.Conn.Exec(ctx, `DELETE FROM tg_messages WHERE created < $1`, expireDate)
+	return tag.RowsAffected(), err
+}
+
+// CleanExpiredFeedback - just clean feedback table
+func (c *Connector) CleanExpiredFeedback(ctx context.Context, expireDate int64) (int64, error) {
+	tag, err := c.Conn.Exec(ctx, `DELETE FROM feedback WHERE created < $1`, expireDate)
+	return tag.RowsAffected(), err
 }
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// ReadSearchProfiles - returns all search profiles of the chat
+func (c *Connector) ReadSearchProfiles(ctx context.Context, chat int64) ([]*structs.SearchProfile, error) {
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT
+		id,
+		chat,
+		name,
+		enable,
+		created,
+		diesel,
+		house,
+		lalafo,
+		photo,
+		usd,
+		kgs,
+		rooms,
+		hide_suspicious
+	FROM search_profile
+	WHERE chat = $1
+	ORDER BY id
+	`,
+		chat,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	profiles := make([]*structs.SearchProfile, 0)
+	for rows.Next() {
+		profile := new(structs.SearchProfile)
+		err := rows.Scan(
+			&profile.Id,
+			&profile.Chat,
+			&profile.Name,
+			&profile.Enable,
+			&profile.Created,
+			&profile.Diesel,
+			&profile.House,
+			&profile.Lalafo,
+			&profile.Photo,
+			&profile.USD,
+			&profile.KGS,
+			&profile.Rooms,
+			&profile.HideSuspicious,
+		)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, rows.Err()
+}
+
+// SaveSearchProfile - creates the profile or updates the profile of the chat
+//  with the same name
+func (c *Connector) SaveSearchProfile(ctx context.Context, profile *structs.SearchProfile) error {
+	return c.Conn.QueryRow(
+		ctx,
+		`INSERT INTO search_profile (chat, name, enable, diesel, house, lalafo, photo, usd, kgs, rooms, hide_suspicious, created)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (chat, name) DO UPDATE SET
+			enable = excluded.enable,
+			diesel = excluded.diesel,
+			house = excluded.house,
+			lalafo = excluded.lalafo,
+			photo = excluded.photo,
+			usd = excluded.usd,
+			kgs = excluded.kgs,
+			rooms = excluded.rooms,
+			hide_suspicious = excluded.hide_suspicious
+		RETURNING id;`,
+		profile.Chat,
+		profile.Name,
+		profile.Enable,
+		profile.Diesel,
+		profile.House,
+		profile.Lalafo,
+		profile.Photo,
+		profile.USD,
+		profile.KGS,
+		profile.Rooms,
+		profile.HideSuspicious,
+		time.Now().Unix(),
+	).Scan(&profile.Id)
+}
+
+// EnableSearchProfile - turns on or off the profile of the chat
+func (c *Connector) EnableSearchProfile(ctx context.Context, chat, id int64, enable bool) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`UPDATE search_profile SET enable = $1 WHERE id = $2 AND chat = $3`,
+		enable,
+		id,
+		chat,
+	)
+	return err
+}
+
+// DeleteSearchProfile - deletes the profile of the chat
+func (c *Connector) DeleteSearchProfile(ctx context.Context, chat, id int64) error {
+	_, err := c.Conn.Exec(ctx, `DELETE FROM search_profile WHERE id = $1 AND chat = $2`, id, chat)
+	return err
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// AddReport - saves the report, one chat has one report for the offer.
+//  Returns how many chats reported the offer and wait for the decision, or
+//  0 when the admin has already resolved the report of this chat, the
+//  decision is not reset by the same report again.
+func (c *Connector) AddReport(ctx context.Context, report *structs.Report) (int, error) {
+	tag, err := c.Conn.Exec(
+		ctx,
+		`INSERT INTO report (chat, user_id, username, offer_id, reason, created)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (chat, offer_id) DO UPDATE SET
+			user_id = excluded.user_id,
+			username = excluded.username,
+			reason = excluded.reason
+		WHERE report.status = 'new';`,
+		report.Chat,
+		report.User,
+		report.Username,
+		report.OfferId,
+		report.Reason,
+		time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, nil
+	}
+
+	count := 0
+	err = c.Conn.QueryRow(
+		ctx,
+		`SELECT count(*) FROM report WHERE offer_id = $1 AND status = $2;`,
+		report.OfferId,
+		structs.ReportNew,
+	).Scan(&count)
+	return count, err
+}
+
+// ResolveReports - the admin decided what to do with the reports of the
+//  offer
+func (c *Connector) ResolveReports(ctx context.Context, offerId uint64, status string) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`UPDATE report SET status = $1 WHERE offer_id = $2 AND status = $3;`,
+		status,
+		offerId,
+		structs.ReportNew,
+	)
+	return err
+}
+
+// HideOffer - hides the offer and the same offers from other sites for
+//  everyone
+func (c *Connector) HideOffer(ctx context.Context, offerId uint64) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`UPDATE offer SET hidden = true
+		WHERE id = $1 OR cluster_id = (SELECT cluster_id FROM offer WHERE id = $1 AND cluster_id != 0);`,
+		offerId,
+	)
+	return err
+}
+
+// BlacklistOfferPhone - adds the phone of the offer to the blacklist of the
+//  chat, structs.GlobalBlacklist hides the phone for everyone. Returns the
+//  phone or the empty string if the offer has no phone.
+func (c *Connector) BlacklistOfferPhone(ctx context.Context, chatId int64, offerId uint64, reason string) (string, error) {
+	phone := ""
+	err := c.Conn.QueryRow(
+		ctx,
+		`INSERT INTO blacklist (chat, phone, reason, created)
+		SELECT $1, phone_norm, $3, $4 FROM offer WHERE id = $2 AND phone_norm != ''
+		ON CONFLICT (chat, phone) DO UPDATE SET reason = excluded.reason
+		RETURNING phone;`,
+		chatId,
+		offerId,
+		reason,
+		time.Now().Unix(),
+	).Scan(&phone)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	return phone, err
+}
+
+// CleanExpiredReports - clean report table from the old reports about the
+//  offers which are already archived
+func (c *Connector) CleanExpiredReports(ctx context.Context, expireDate int64) (int64, error) {
+	tag, err := c.Conn.Exec(
+		ctx,
+		`DELETE FROM report r
+		WHERE r.created < $1
+			AND NOT EXISTS (SELECT 1 FROM offer of WHERE of.id = r.offer_id);`,
+		expireDate,
+	)
+	return tag.RowsAffected(), err
+}
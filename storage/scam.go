@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// ReadReportedPhones - the phones which are in the global blacklist or on
+//  the offers with reports which were not dismissed
+func (c *Connector) ReadReportedPhones(ctx context.Context, phones []string) (map[string]bool, error) {
+	reported := make(map[string]bool)
+	if len(phones) == 0 {
+		return reported, nil
+	}
+
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT phone FROM blacklist WHERE chat = $1 AND phone = ANY($2)
+		UNION
+		SELECT of.phone_norm
+		FROM report r
+		JOIN offer of on (of.id = r.offer_id)
+		WHERE r.status != $3 AND of.phone_norm = ANY($2);`,
+		structs.GlobalBlacklist,
+		phones,
+		structs.ReportDismissed,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var phone string
+		if err := rows.Scan(&phone); err != nil {
+			return nil, err
+		}
+		reported[phone] = true
+	}
+	return reported, rows.Err()
+}
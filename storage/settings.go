@@ -34,8 +34,12 @@ func (c *Connector) UpdateSettings(ctx context.Context, chat *structs.Chat) erro
 		lalafo = $4,
 		photo = $5,
 		kgs = $6,
-		usd = $7
-	WHERE id = $8
+		usd = $7,
+		rooms = $8,
+		vote_rule = $9,
+		timezone = $10,
+		hide_suspicious = $11
+	WHERE id = $12
 	`,
 		chat.Enable,
 		chat.Diesel,
@@ -44,6 +48,10 @@ func (c *Connector) UpdateSettings(ctx context.Context, chat *structs.Chat) erro
 		chat.Photo,
 		chat.KGS,
 		chat.USD,
+		chat.Rooms,
+		chat.VoteRule,
+		chat.Timezone,
+		chat.HideSuspicious,
 		chat.Id,
 	)
 	return err
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// Snooze - hides the offer until the date, then the matcher sends it again.
+//  Returns the messages of the offer to delete them from the chat, the
+//  messages are kept in tg_messages so the offer is not sent as a new one.
+func (c *Connector) Snooze(ctx context.Context, offerId uint64, chatId int64, until int64) ([]int, error) {
+	_, err := c.Conn.Exec(
+		ctx,
+		`INSERT INTO snooze (chat, offer_id, until, created)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chat, offer_id) DO UPDATE SET until = excluded.until;`,
+		chatId,
+		offerId,
+		until,
+		time.Now().Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT message_id FROM tg_messages WHERE offer_id = $1 AND chat = $2;`,
+		offerId,
+		chatId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	msgIds := make([]int, 0)
+	for rows.Next() {
+		var mId int
+		if err := rows.Scan(&mId); err != nil {
+			return nil, err
+		}
+		msgIds = append(msgIds, mId)
+	}
+	return msgIds, rows.Err()
+}
+
+// ReadDueSnoozes - the snoozed offers of the chat which should be sent
+//  again and which are already checked by the snooze checker. Disliked
+//  offers are skipped.
+func (c *Connector) ReadDueSnoozes(ctx context.Context, chatId int64, now int64) ([]*structs.Snooze, error) {
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT s.chat, s.offer_id, s.checked, `+offerColumns+`
+		FROM snooze s
+		JOIN offer of on (of.id = s.offer_id) `+marketJoin+`
+		WHERE s.chat = $1
+			AND s.until <= $2
+			AND s.checked != ''
+			AND NOT of.hidden
+			AND NOT EXISTS (
+				SELECT 1
+				FROM blacklist bl
+				WHERE bl.chat IN (0, s.chat)
+					AND bl.phone = of.phone_norm
+					AND of.phone_norm != ''
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM answer a WHERE a.chat = s.chat AND a.offer_id = s.offer_id AND a.dislike
+			)
+		ORDER BY s.until;`,
+		chatId,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	snoozes := make([]*structs.Snooze, 0)
+	for rows.Next() {
+		snooze := &structs.Snooze{Offer: new(structs.Offer)}
+		fields := append([]interface{}{&snooze.Chat, &snooze.OfferId, &snooze.Checked}, offerFields(snooze.Offer)...)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, err
+		}
+		snoozes = append(snoozes, snooze)
+	}
+	return snoozes, rows.Err()
+}
+
+// ReadSnoozesToCheck - the due snoozed offers which are not checked yet,
+//  only id, site and url are filled. The offer snoozed by several chats is
+//  read once.
+func (c *Connector) ReadSnoozesToCheck(ctx context.Context, now int64, limit int) ([]*structs.Offer, error) {
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT of.id, of.site, of.url
+		FROM offer of
+		WHERE of.id IN (SELECT s.offer_id FROM snooze s WHERE s.until <= $1 AND s.checked = '')
+		ORDER BY of.id
+		LIMIT $2;`,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	offers := make([]*structs.Offer, 0)
+	for rows.Next() {
+		offer := new(structs.Offer)
+		if err := rows.Scan(&offer.Id, &offer.Site, &offer.Url); err != nil {
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+	return offers, rows.Err()
+}
+
+// SetSnoozeChecked - saves what the snooze checker found for all due
+//  snoozes of the offer
+func (c *Connector) SetSnoozeChecked(ctx context.Context, offerId uint64, now int64, checked string) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`UPDATE snooze SET checked = $3 WHERE offer_id = $1 AND until <= $2 AND checked = '';`,
+		offerId,
+		now,
+		checked,
+	)
+	return err
+}
+
+// AddSnoozeAttempt - counts the failed check of the offer and returns how
+//  many checks failed
+func (c *Connector) AddSnoozeAttempt(ctx context.Context, offerId uint64, now int64) (int, error) {
+	var attempts int
+	err := c.Conn.QueryRow(
+		ctx,
+		`WITH failed AS (
+			UPDATE snooze SET attempts = attempts + 1
+			WHERE offer_id = $1 AND until <= $2 AND checked = ''
+			RETURNING attempts
+		)
+		SELECT coalesce(max(attempts), 0) FROM failed;`,
+		offerId,
+		now,
+	).Scan(&attempts)
+	return attempts, err
+}
+
+// DeleteSnooze - the offer is sent again or it is gone
+func (c *Connector) DeleteSnooze(ctx context.Context, offerId uint64, chatId int64) error {
+	_, err := c.Conn.Exec(ctx, `DELETE FROM snooze WHERE offer_id = $1 AND chat = $2;`, offerId, chatId)
+	return err
+}
+
+// CleanExpiredSnoozes - clean snooze table from the old snoozes and the
+//  snoozes of deleted offers
+func (c *Connector) CleanExpiredSnoozes(ctx context.Context, expireDate int64) (int64, error) {
+	tag, err := c.Conn.Exec(
+		ctx,
+		`DELETE FROM snooze s
+		WHERE s.until < $1 OR NOT EXISTS (SELECT 1 FROM offer of WHERE of.id = s.offer_id)`,
+		expireDate,
+	)
+	return tag.RowsAffected(), err
+}
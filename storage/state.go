@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// ReadChatState - returns the state of the dialog with the chat or nil if
+//  the chat is not in a dialog
+func (c *Connector) ReadChatState(ctx context.Context, chat int64) (*structs.ChatState, error) {
+	state := &structs.ChatState{}
+	data := ""
+	messages := make([]int32, 0)
+	err := c.Conn.QueryRow(
+		ctx,
+		`SELECT chat, state, data, retries, deadline, messages FROM chat_state WHERE chat = $1`,
+		chat,
+	).Scan(
+		&state.Chat,
+		&state.Name,
+		&data,
+		&state.Retries,
+		&state.Deadline,
+		&messages,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	state.Data = make(map[string]string)
+	if err := json.Unmarshal([]byte(data), &state.Data); err != nil {
+		return nil, err
+	}
+
+	state.Messages = make([]int, 0, len(messages))
+	for _, id := range messages {
+		state.Messages = append(state.Messages, int(id))
+	}
+	return state, nil
+}
+
+// SaveChatState - creates or replaces the state of the dialog with the chat
+func (c *Connector) SaveChatState(ctx context.Context, state *structs.ChatState) error {
+	data, err := json.Marshal(state.Data)
+	if err != nil {
+		return err
+	}
+
+	messages := make([]int32, 0, len(state.Messages))
+	for _, id := range state.Messages {
+		messages = append(messages, int32(id))
+	}
+
+	_, err = c.Conn.Exec(
+		ctx,
+		`INSERT INTO chat_state (chat, state, data, retries, deadline, messages, created)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (chat) DO UPDATE SET
+			state = excluded.state,
+			data = excluded.data,
+			retries = excluded.retries,
+			deadline = excluded.deadline,
+			messages = excluded.messages;`,
+		state.Chat,
+		state.Name,
+		string(data),
+		state.Retries,
+		state.Deadline,
+		messages,
+		time.Now().Unix(),
+	)
+	return err
+}
+
+// DeleteChatState - the dialog with the chat is over
+func (c *Connector) DeleteChatState(ctx context.Context, chat int64) error {
+	_, err := c.Conn.Exec(ctx, `DELETE FROM chat_state WHERE chat = $1`, chat)
+	return err
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// SetOfferStatus - changes the status of the offer in the pipeline of the
+//  chat, StatusNew removes the offer from the pipeline
+func (c *Connector) SetOfferStatus(ctx context.Context, chatId int64, offerId uint64, status string) error {
+	if status == structs.StatusNew {
+		_, err := c.Conn.Exec(
+			ctx,
+			`DELETE FROM offer_status WHERE chat = $1 AND offer_id = $2;`,
+			chatId,
+			offerId,
+		)
+		return err
+	}
+
+	now := time.Now().Unix()
+	_, err := c.Conn.Exec(
+		ctx,
+		`INSERT INTO offer_status (chat, offer_id, status, created, updated)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (chat, offer_id) DO UPDATE SET
+			status = excluded.status,
+			updated = excluded.updated;`,
+		chatId,
+		offerId,
+		status,
+		now,
+	)
+	return err
+}
+
+// ReadOfferStatus - the status of the offer in the chat, StatusNew if the
+//  offer is not in the pipeline
+func (c *Connector) ReadOfferStatus(ctx context.Context, chatId int64, offerId uint64) (string, error) {
+	status := structs.StatusNew
+	err := c.Conn.QueryRow(
+		ctx,
+		`SELECT status FROM offer_status WHERE chat = $1 AND offer_id = $2;`,
+		chatId,
+		offerId,
+	).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return structs.StatusNew, nil
+	}
+	return status, err
+}
+
+// ReadPipeline - the offers of the chat which have a status, the last
+//  changed first
+func (c *Connector) ReadPipeline(ctx context.Context, chatId int64) ([]*structs.Offer, error) {
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT `+offerColumns+`, os.status
+		FROM offer of `+marketJoin+`
+		JOIN offer_status os on (os.offer_id = of.id)
+		WHERE os.chat = $1
+		ORDER BY os.updated DESC, of.id;`,
+		chatId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	offers := make([]*structs.Offer, 0)
+	for rows.Next() {
+		offer := new(structs.Offer)
+		if err := rows.Scan(append(offerFields(offer), &offer.Status)...); err != nil {
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+	return offers, rows.Err()
+}
+
+// CleanExpiredStatuses - clean offer_status table from the old statuses of
+//  the offers which are already archived
+func (c *Connector) CleanExpiredStatuses(ctx context.Context, expireDate int64) (int64, error) {
+	tag, err := c.Conn.Exec(
+		ctx,
+		`DELETE FROM offer_status os
+		WHERE os.updated < $1
+			AND NOT EXISTS (SELECT 1 FROM offer of WHERE of.id = os.offer_id);`,
+		expireDate,
+	)
+	return tag.RowsAffected(), err
+}
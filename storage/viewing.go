@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// SaveViewing - saves the appointment to see the flat
+func (c *Connector) SaveViewing(ctx context.Context, viewing *structs.Viewing) error {
+	return c.Conn.QueryRow(
+		ctx,
+		`INSERT INTO viewing (chat, offer_id, at, created)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id;`,
+		viewing.Chat,
+		viewing.OfferId,
+		viewing.At,
+		time.Now().Unix(),
+	).Scan(&viewing.Id)
+}
+
+// DeleteViewing - cancels the appointment
+func (c *Connector) DeleteViewing(ctx context.Context, chatId, id int64) error {
+	_, err := c.Conn.Exec(ctx, `DELETE FROM viewing WHERE chat = $1 AND id = $2;`, chatId, id)
+	return err
+}
+
+// ReadViewings - the appointments of the chat after the date, the nearest
+//  first
+func (c *Connector) ReadViewings(ctx context.Context, chatId, from int64) ([]*structs.Viewing, error) {
+	return c.readViewings(
+		ctx,
+		`SELECT v.id, v.chat, v.offer_id, v.at, ch.timezone, `+offerColumns+`
+		FROM viewing v
+		JOIN chat ch on (ch.id = v.chat)
+		JOIN offer of on (of.id = v.offer_id) `+marketJoin+`
+		WHERE v.chat = $1 AND v.at >= $2
+		ORDER BY v.at;`,
+		chatId,
+		from,
+	)
+}
+
+// ReadDueViewings - the appointments of all chats which start before till
+//  and the chat was not reminded about. The ones which started after since
+//  are read too, so the reminders missed while the bot was down are sent.
+func (c *Connector) ReadDueViewings(ctx context.Context, till, since int64) ([]*structs.Viewing, error) {
+	return c.readViewings(
+		ctx,
+		`SELECT v.id, v.chat, v.offer_id, v.at, ch.timezone, `+offerColumns+`
+		FROM viewing v
+		JOIN chat ch on (ch.id = v.chat)
+		JOIN offer of on (of.id = v.offer_id) `+marketJoin+`
+		WHERE NOT v.reminded AND v.at <= $1 AND v.at > $2
+		ORDER BY v.at;`,
+		till,
+		since,
+	)
+}
+
+// SetViewingReminded - the reminder is sent, do not send it again
+func (c *Connector) SetViewingReminded(ctx context.Context, id int64) error {
+	_, err := c.Conn.Exec(ctx, `UPDATE viewing SET reminded = true WHERE id = $1;`, id)
+	return err
+}
+
+// CleanExpiredViewings - just clean viewing table
+func (c *Connector) CleanExpiredViewings(ctx context.Context, expireDate int64) (int64, error) {
+	tag, err := c.Conn.Exec(ctx, `DELETE FROM viewing WHERE at < $1`, expireDate)
+	return tag.RowsAffected(), err
+}
+
+func (c *Connector) readViewings(ctx context.Context, query string, args ...interface{}) ([]*structs.Viewing, error) {
+	rows, err := c.Conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	viewings := make([]*structs.Viewing, 0)
+	for rows.Next() {
+		viewing := &structs.Viewing{Offer: new(structs.Offer)}
+		fields := []interface{}{
+			&viewing.Id,
+			&viewing.Chat,
+			&viewing.OfferId,
+			&viewing.At,
+			&viewing.Timezone,
+		}
+		if err := rows.Scan(append(fields, offerFields(viewing.Offer)...)...); err != nil {
+			return nil, err
+		}
+		viewings = append(viewings, viewing)
+	}
+	return viewings, rows.Err()
+}
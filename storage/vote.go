@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/comov/hsearch/structs"
+)
+
+// Vote - saves the vote of the group member for the offer, the member can
+//  change the vote
+func (c *Connector) Vote(ctx context.Context, chatId int64, offerId uint64, vote *structs.Vote) error {
+	_, err := c.Conn.Exec(
+		ctx,
+		`INSERT INTO vote (chat, offer_id, user_id, username, value, created)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (chat, offer_id, user_id) DO UPDATE SET
+			username = excluded.username,
+			value = excluded.value;`,
+		chatId,
+		offerId,
+		vote.User,
+		vote.Username,
+		vote.Value,
+		time.Now().Unix(),
+	)
+	return err
+}
+
+// ReadVotes - returns the votes of the group for the offer in the order they
+//  were given
+func (c *Connector) ReadVotes(ctx context.Context, chatId int64, offerId uint64) ([]*structs.Vote, error) {
+	rows, err := c.Conn.Query(
+		ctx,
+		`SELECT user_id, username, value FROM vote WHERE chat = $1 AND offer_id = $2 ORDER BY id;`,
+		chatId,
+		offerId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	votes := make([]*structs.Vote, 0)
+	for rows.Next() {
+		vote := new(structs.Vote)
+		if err := rows.Scan(&vote.User, &vote.Username, &vote.Value); err != nil {
+			return nil, err
+		}
+		votes = append(votes, vote)
+	}
+	return votes, rows.Err()
+}
+
+// CleanExpiredVotes - just clean vote table
+func (c *Connector) CleanExpiredVotes(ctx context.Context, expireDate int64) (int64, error) {
+	tag, err := c.Conn.Exec(ctx, `DELETE FROM vote WHERE created < $1`, expireDate)
+	return tag.RowsAffected(), err
+}
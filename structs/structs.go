@@ -20,8 +20,62 @@ const (
 	SiteDiesel = "diesel"
 	SiteLalafo = "lalafo"
 	SiteHouse  = "house"
+
+	// VoteAny - in groups the offer is hidden after the first 👎, VoteMajority
+	//  - when more than a half of members voted 👎, VoteAll - when everyone
+	VoteAny      = "any"
+	VoteMajority = "majority"
+	VoteAll      = "all"
+)
+
+// the statuses of the offer in the pipeline of the chat, from the first call
+//  to the landlord to the result
+const (
+	StatusNew       = "new"
+	StatusContacted = "contacted"
+	StatusViewing   = "viewing"
+	StatusViewed    = "viewed"
+	StatusRejected  = "rejected"
+	StatusRented    = "rented"
+)
+
+// the reasons of the report about the offer and what the admin decided
+const (
+	ReportPrice  = "price"
+	ReportRented = "rented"
+	ReportAgency = "agency"
+	ReportScam   = "scam"
+
+	ReportNew         = "new"
+	ReportHidden      = "hidden"
+	ReportBlacklisted = "blacklisted"
+	ReportDismissed   = "dismissed"
 )
 
+// what the snooze checker found on the site about the snoozed offer, the
+//  matcher sends only the checked snoozes
+const (
+	SnoozeUnchecked = ""
+	SnoozeAlive     = "alive"
+	SnoozeGone      = "gone"
+)
+
+// GlobalBlacklist - the chat of the phones which are hidden for everyone
+const GlobalBlacklist = 0
+
+// DefaultTimezone - the timezone of the chat if it was not changed
+const DefaultTimezone = "Asia/Bishkek"
+
+// Statuses - the statuses in the order of the pipeline
+var Statuses = []string{
+	StatusNew,
+	StatusContacted,
+	StatusViewing,
+	StatusViewed,
+	StatusRejected,
+	StatusRented,
+}
+
 type (
 	// Price - is a custom type for storing the filter as a string.
 	Price [2]int // {from, to}
@@ -37,15 +91,42 @@ type (
 		Created  int64
 
 		// settings
-		Enable bool
+		Enable   bool
+		VoteRule string // when the offer is hidden in groups
+		Timezone string // the time of viewings is asked in it
+
+		// filters of the chat, they are used when the chat has no enabled
+		//  profiles
+		Filters
+	}
+
+	// Filters - where to search and what offers to send
+	Filters struct {
+		// sites
 		Diesel bool
 		Lalafo bool
 		House  bool
 
-		// filters
 		Photo bool
 		USD   Price
 		KGS   Price
+		Rooms Price
+
+		// HideSuspicious - do not send the offers which look like a fraud
+		HideSuspicious bool
+	}
+
+	// SearchProfile - a named search of the chat with its own filters. If
+	//  the chat has enabled profiles, offers are matched by them and
+	//  the card is labelled with the name of the profile.
+	SearchProfile struct {
+		Id      int64
+		Chat    int64
+		Name    string
+		Enable  bool
+		Created int64
+
+		Filters
 	}
 
 	// Offer - posted on the site.
@@ -68,6 +149,37 @@ type (
 		Body       string
 		Images     int
 		ImagesList []string
+		Profile    string // name of the search profile which matched the offer
+		Liked      bool   // the offer is in favorites of the chat
+		Votes      []*Vote
+		Status     string // the status in the pipeline of the chat
+		Notes      []*Note
+
+		// duplicates
+		ClusterId uint64   // id of the first offer with the same flat
+		PhoneNorm string   // phone in the +996XXXXXXXXX format
+		Signature []uint32 // MinHash signature of the body
+		Links     []string // urls of the same flat on other sites
+
+		// photos
+		Photos     []*Image // ImagesList with perceptual hashes
+		SamePhotos int      // number of other clusters with the same photos
+
+		ScamScore   int      // how suspicious the offer is, see scam.Score
+		ScamReasons []string // scam.Reason* codes
+
+		MarketMedian int // the median price of the same flats in the district
+	}
+
+	// Image - offer picture. Path is the url on the site, Hash is the
+	//  perceptual hash of the picture or 0 if it was not loaded, Key is the
+	//  key of our copy in the image store or empty if there is no copy,
+	//  FileId is the id of the photo on Telegram servers after the first send.
+	Image struct {
+		Path   string
+		Hash   uint64
+		Key    string
+		FileId string
 	}
 
 	// Answer - is a ManyToMany to store the user's reaction to the offer.
@@ -76,6 +188,76 @@ type (
 		Chat    uint64
 		Offer   uint64
 		Dislike bool
+		Liked   bool
+		Reason  string // why the offer was disliked, optional
+	}
+
+	// Vote - 👍 (Value 1) or 👎 (Value -1) of a group member for the offer
+	Vote struct {
+		User     int64
+		Username string
+		Value    int
+	}
+
+	// Report - the complaint of the chat member about the offer
+	Report struct {
+		Id       int64
+		Chat     int64
+		User     int64
+		Username string
+		OfferId  uint64
+		Reason   string
+		Status   string
+	}
+
+	// BlacklistEntry - the phone which offers are not sent to the chat
+	BlacklistEntry struct {
+		Id      int64
+		Chat    int64
+		Phone   string
+		Reason  string
+		Created int64
+	}
+
+	// MarketStat - the prices of the same flats in the district for the
+	//  last days
+	MarketStat struct {
+		City     string
+		District string
+		Rooms    int
+		Currency string
+		P25      int
+		Median   int
+		P75      int
+		Samples  int
+	}
+
+	// Note - the note of the chat member about the offer
+	Note struct {
+		Id       int64
+		User     int64
+		Username string
+		Body     string
+		Created  int64
+	}
+
+	// Viewing - the appointment to see the flat, At is unix time
+	Viewing struct {
+		Id       int64
+		Chat     int64
+		OfferId  uint64
+		At       int64
+		Timezone string // timezone of the chat
+		Offer    *Offer
+	}
+
+	// Snooze - the snoozed offer which is due, Checked is what the snooze
+	//  checker found on the site
+	Snooze struct {
+		Chat    int64
+		OfferId uint64
+		Checked string
+		Offer   *Offer
 	}
 
 	// Feedback - a feedback structure hoping to get bug reports and not
@@ -85,6 +267,18 @@ type (
 		Chat     int64
 		Body     string
 	}
+
+	// ChatState - the state of a dialog with the chat. Data is for the step
+	//  handlers, Messages are the questions and wrong answers to delete when
+	//  the dialog is over.
+	ChatState struct {
+		Chat     int64
+		Name     string
+		Data     map[string]string
+		Retries  int
+		Deadline int64
+		Messages []int
+	}
 )
 
 // String - displays how the price was written in bd
@@ -108,6 +302,21 @@ func (p *Price) Scan(value interface{}) error {
 	return nil
 }
 
+// PhotoHashes - returns perceptual hashes of the loaded photos
+func (o *Offer) PhotoHashes() []uint64 {
+	hashes := make([]uint64, 0, len(o.Photos))
+	for _, img := range o.Photos {
+		if img.Hash != 0 {
+			hashes = append(hashes, img.Hash)
+		}
+	}
+	return hashes
+}
+
 func (p *Chat) IsChannel() bool {
 	return p.Type == TypeChannel
 }
+
+func (p *Chat) IsGroup() bool {
+	return p.Type == TypeGroup || p.Type == TypeSupergroup
+}